@@ -33,22 +33,30 @@ type Application struct {
 	state     ui.ProjectState
 	stateCh   chan ui.ProjectState
 	lastError error
+
+	// pinned restricts the context sent with each message to these package
+	// patterns; set with the /pin command.
+	pinMu  sync.Mutex
+	pinned []string
 }
 
 func New(cfg *config.Config, baseDir string, outputFile string, mode string) *Application {
 	app := &Application{
 		baseDir:    baseDir,
 		app:        tview.NewApplication(),
-		parser:     parser.New(),
+		parser:     parser.New(cfg),
 		patcher:    patcher.New(baseDir),
 		llm:        llm.New(cfg),
 		outputFile: outputFile,
 		Mode:       mode,
 		state:      ui.StateUnknown,
 		stateCh:    make(chan ui.ProjectState, 1),
+		pinned:     cfg.Context.Packages,
 	}
+	app.patcher.SetForce(cfg.Patch.AllowGenerated)
 	app.ui = ui.New(app.app, app.messageHandler)
 	app.ui.SetModeChangeCallback(app.modeChangeHandler)
+	app.llm.SetRetryCallback(app.retryHandler)
 	app.ui.SetMode(app.Mode)
 	app.ui.SetBaseDir(baseDir)
 	app.ui.SetModelName(cfg.LLM.Model)
@@ -79,22 +87,50 @@ func (a *Application) messageHandler(message string) {
 		a.app.Stop()
 		return
 	}
+	if fields := strings.Fields(message); len(fields) > 0 && (fields[0] == "/pin" || fields[0] == "/unpin") {
+		a.pin(fields[1:])
+		return
+	}
 
 	a.ui.StartLoading()
 
 	go func() {
 		defer a.ui.StopLoading()
 
-		structure, err := a.parser.ParseProject(a.baseDir)
+		project, err := a.parser.LoadPackages(a.baseDir, a.pinnedPackages())
 		if err != nil {
 			a.setState(ui.StateError)
 			a.setError(fmt.Errorf("Could not parse the project: %w ", err))
 			a.postSystemMessage("ERROR: Could not parse the project: " + err.Error())
 			return
 		}
+		structure := a.parser.Compact(project, message, a.llm.ContextBudget())
+		if len(project.Diagnostics) > 0 {
+			a.postSystemMessage(formatDiagnostics(project.Diagnostics))
+		}
+
+		// Rank the files from the same parse, unless the ranking is unused.
+		var hints llm.FileHints
+		if a.llm.UsesFileHints() {
+			shortlist := parser.NewIndex(project).Shortlist(message)
+			hints = llm.FileHints{Files: shortlist.Files, Confident: shortlist.Confident}
+		}
+		if a.llm.SkipsFileSelection(hints) {
+			a.postSystemMessage("Files ranked locally: " + strings.Join(hints.Files, ", "))
+		}
 
-		// Send to LLM
-		response, err := a.llm.SendMessage(message, structure, a.Mode)
+		// Send to LLM, showing the answer as it streams in
+		usedBefore := a.llm.Usage()
+		streamed := false
+		onText := func(text string) {
+			if !streamed {
+				streamed = true
+				a.postSystemMessage("Mode: " + a.Mode)
+				text = "\n System: " + text
+			}
+			a.ui.QueueChatText(text)
+		}
+		response, err := a.llm.SendMessage(message, structure, a.Mode, hints, onText)
 		if err != nil {
 			a.setState(ui.StateError)
 			a.setError(fmt.Errorf("LLM error: %w", err))
@@ -103,8 +139,14 @@ func (a *Application) messageHandler(message string) {
 		}
 		// response := manualPatch
 
-		a.postSystemMessage("Mode: " + a.Mode)
-		a.postSystemMessage(response)
+		if !streamed {
+			a.postSystemMessage("Mode: " + a.Mode)
+			a.postSystemMessage(response)
+		}
+		if used := a.llm.Usage(); used != usedBefore {
+			a.postSystemMessage(fmt.Sprintf("Tokens used: %d in, %d out",
+				used.InputTokens-usedBefore.InputTokens, used.OutputTokens-usedBefore.OutputTokens))
+		}
 
 		// Append to .vogte/chatbot.log
 		logDir := filepath.Join(".", ".vogte")
@@ -133,6 +175,31 @@ func (app *Application) modeChangeHandler(newMode string) {
 	// app.postSystemMessage(fmt.Sprintf("Mode changed to: %s", newMode))
 }
 
+// retryHandler reports a failed LLM request that is about to be sent again.
+func (app *Application) retryHandler(attempt, maxAttempts int, delay time.Duration, err error) {
+	app.postSystemMessage(fmt.Sprintf("Request failed, retrying in %s (attempt %d of %d): %v",
+		delay.Round(100*time.Millisecond), attempt, maxAttempts, err))
+}
+
+// pin restricts the context of the following messages to the given package
+// patterns, or lifts the restriction when there are none.
+func (a *Application) pin(patterns []string) {
+	a.pinMu.Lock()
+	a.pinned = patterns
+	a.pinMu.Unlock()
+	if len(patterns) == 0 {
+		a.postSystemMessage("Unpinned: the context covers the whole project")
+		return
+	}
+	a.postSystemMessage("Pinned: the context is restricted to " + strings.Join(patterns, ", ") + " and the packages they import")
+}
+
+func (a *Application) pinnedPackages() []string {
+	a.pinMu.Lock()
+	defer a.pinMu.Unlock()
+	return a.pinned
+}
+
 func (app *Application) postSystemMessage(message string) {
 	// Schedule the UI update to run on the main UI thread.
 	app.app.QueueUpdateDraw(func() {
@@ -152,8 +219,31 @@ func (app *Application) GetMode() string {
 	return app.Mode
 }
 
-func (a *Application) Parse() (string, error) {
-	return a.parser.ParseProject(a.baseDir)
+func (a *Application) Parse(format parser.Format) (string, []parser.Diagnostic, error) {
+	return a.parser.ParseProjectAs(a.baseDir, format)
+}
+
+// formatDiagnostics renders a warning listing the files left out of the
+// context, followed by the type errors in files that are still in it.
+func formatDiagnostics(diagnostics []parser.Diagnostic) string {
+	var leftOut, kept []string
+	for _, d := range diagnostics {
+		if d.LeftOut() {
+			leftOut = append(leftOut, "  "+d.String())
+		} else {
+			kept = append(kept, "  "+d.String())
+		}
+	}
+	var lines []string
+	if len(leftOut) > 0 {
+		lines = append(lines, fmt.Sprintf("WARNING: %d problem(s) found while parsing, affected files are left out of the context:", len(leftOut)))
+		lines = append(lines, leftOut...)
+	}
+	if len(kept) > 0 {
+		lines = append(lines, fmt.Sprintf("WARNING: %d type error(s) found, affected files are still in the context:", len(kept)))
+		lines = append(lines, kept...)
+	}
+	return strings.Join(lines, "\n")
 }
 
 func (a *Application) stateMonitor() {
@@ -5,14 +5,26 @@ import (
 	"os"
 
 	"github.com/piqoni/vogte/app"
+	"github.com/piqoni/vogte/parser"
 )
 
-func Run(application *app.Application, outputPath string) error {
-	structure, err := application.Parse()
+// Run writes the project context in the given format to outputPath, or to
+// stdout when outputPath is "-".
+func Run(application *app.Application, outputPath string, format parser.Format) error {
+	structure, diagnostics, err := application.Parse(format)
 	if err != nil {
 		return fmt.Errorf("could not parse project: %w", err)
 	}
+	for _, d := range diagnostics {
+		fmt.Fprintf(os.Stderr, "⚠️  %s\n", d)
+	}
 
+	if outputPath == "-" {
+		if _, err := os.Stdout.WriteString(structure); err != nil {
+			return fmt.Errorf("error writing to stdout: %w", err)
+		}
+		return nil
+	}
 	if err := os.WriteFile(outputPath, []byte(structure), 0644); err != nil {
 		return fmt.Errorf("error writing to file %s: %w", outputPath, err)
 	}
@@ -3,15 +3,76 @@ package config
 import (
 	"encoding/json"
 	"os"
-	"strings"
+	"slices"
 )
 
+// defaultExclude is always part of Context.Exclude, so an "exclude" list in
+// the config file adds to it rather than replacing it.
+var defaultExclude = []string{"**/testdata/**"}
+
 type Config struct {
 	LLM struct {
+		// Provider names the backend requests are sent to: "openai",
+		// "anthropic", "bedrock" or one registered with
+		// llm.RegisterProvider. Empty picks it from the model name.
+		Provider string `json:"provider"`
 		APIKey   string `json:"api_key"`
 		Model    string `json:"model"`
 		Endpoint string `json:"endpoint"`
+		// FileSelection decides how the files sent in full are chosen:
+		// "hint" (the default) asks the model and passes it the files
+		// ranked by a local search, "model" asks without the ranking, and
+		// "local" uses the ranked files directly when the match is clear.
+		FileSelection string `json:"file_selection"`
+		// MaxAttempts is how many times a request is sent when it fails
+		// with a rate limit, an overloaded server or a dropped connection.
+		// Zero uses the default of 4; one disables retries.
+		MaxAttempts int `json:"max_attempts"`
 	} `json:"llm"`
+	Context struct {
+		// TypeCheck loads the module with go/packages and adds resolved
+		// method sets and interface implementations to the context.
+		TypeCheck bool `json:"type_check"`
+		// MaxTokens caps the size of the blueprint. Zero derives the budget
+		// from the model's context window.
+		MaxTokens int `json:"max_tokens"`
+		// Include and Exclude are globs relative to the project root that
+		// select the summarized files. "**" matches any number of directories.
+		// Exclude always contains "**/testdata/**".
+		Include []string `json:"include"`
+		Exclude []string `json:"exclude"`
+		// SkipGenerated leaves files with a "Code generated ... DO NOT EDIT"
+		// header out entirely instead of listing them on one line.
+		SkipGenerated bool `json:"skip_generated"`
+		// Docs selects how much of each doc comment is kept: "none",
+		// "first-sentence" (the default) or "full".
+		Docs string `json:"docs"`
+		// SkipTests leaves _test.go files out. Otherwise each one is listed
+		// on one line with its tests and helpers.
+		SkipTests bool `json:"skip_tests"`
+		// APIOnly limits the context to exported declarations, for
+		// libraries whose public API is what matters.
+		APIOnly bool `json:"api_only"`
+		// Callers adds, for each exported function, the functions that call
+		// it, found from the syntax alone.
+		Callers bool `json:"callers"`
+		// Dependencies adds the signatures of the third-party identifiers
+		// the project references, read offline from vendor/ or GOMODCACHE.
+		Dependencies bool `json:"dependencies"`
+		// GOOS, GOARCH and Tags select the files whose build constraints
+		// are satisfied; the host's values are used when empty. Other
+		// variants are only mentioned.
+		GOOS   string   `json:"goos"`
+		GOARCH string   `json:"goarch"`
+		Tags   []string `json:"tags"`
+		// Packages restricts the context to matching packages, such as
+		// "./internal/billing/...", plus signatures of what they import.
+		Packages []string `json:"packages"`
+	} `json:"context"`
+	Patch struct {
+		// AllowGenerated lets AGENT mode edit generated files.
+		AllowGenerated bool `json:"allow_generated"`
+	} `json:"patch"`
 }
 
 func (cfg *Config) SetModel(model string) {
@@ -19,24 +80,25 @@ func (cfg *Config) SetModel(model string) {
 	cfg.ApplyProviderByModel()
 }
 
-// If model starts with "arn:aws:bedrock:", it's a Bedrock model (no API key needed)
-// If model starts with "claude-", it will use Anthropic endpoint and ANTHROPIC_API_KEY
-// Otherwise, defualt to OpenAI endpoint and OPENAI_API_KEY (if present).
+// providerDefaults sets the endpoint and API key of the provider a
+// configuration selects. Package llm installs it, since that is where
+// providers are registered along with the models they claim.
+var providerDefaults func(cfg *Config)
+
+// SetProviderDefaults installs the function ApplyProviderByModel uses to set
+// the endpoint and API key of the selected provider.
+func SetProviderDefaults(fn func(cfg *Config)) {
+	providerDefaults = fn
+}
+
+// ApplyProviderByModel sets the endpoint and API key of the provider the
+// configuration selects: the provider setting, or else the registered provider
+// claiming the model or endpoint. Each provider keeps its own defaults, read
+// from the environment where it has an API key variable; a local model server
+// keeps its endpoint.
 func (cfg *Config) ApplyProviderByModel() {
-	if strings.HasPrefix(strings.TrimSpace(cfg.LLM.Model), "arn:aws:bedrock:") {
-		// Bedrock models don't need API keys or endpoints
-		cfg.LLM.Endpoint = ""
-		cfg.LLM.APIKey = ""
-	} else if strings.HasPrefix(strings.ToLower(cfg.LLM.Model), "claude-") {
-		cfg.LLM.Endpoint = "https://api.anthropic.com/v1/messages"
-		if k := os.Getenv("ANTHROPIC_API_KEY"); k != "" {
-			cfg.LLM.APIKey = k
-		}
-	} else {
-		cfg.LLM.Endpoint = "https://api.openai.com/v1/chat/completions"
-		if k := os.Getenv("OPENAI_API_KEY"); k != "" {
-			cfg.LLM.APIKey = k
-		}
+	if providerDefaults != nil {
+		providerDefaults(cfg)
 	}
 }
 
@@ -55,6 +117,11 @@ func Load(configPath string) *Config {
 	if err := json.Unmarshal(data, cfg); err != nil {
 		return cfg
 	}
+	for _, pattern := range defaultExclude {
+		if !slices.Contains(cfg.Context.Exclude, pattern) {
+			cfg.Context.Exclude = append(cfg.Context.Exclude, pattern)
+		}
+	}
 
 	return cfg
 }
@@ -66,5 +133,7 @@ func defaultConfig() *Config {
 		cfg.LLM.Model = "gpt-5"
 	}
 	cfg.ApplyProviderByModel()
+	cfg.Context.Exclude = slices.Clone(defaultExclude)
+	cfg.Context.Docs = "first-sentence"
 	return cfg
 }
@@ -0,0 +1,71 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/piqoni/vogte/config"
+	// Providers register their defaults with the config package.
+	_ "github.com/piqoni/vogte/llm"
+)
+
+func TestSetModelKeepsLocalEndpoint(t *testing.T) {
+	tests := []struct {
+		provider string
+		endpoint string
+	}{
+		{"", "http://localhost:11434"},
+		{"local", "http://localhost:8080/v1"},
+	}
+	for _, tt := range tests {
+		cfg := &config.Config{}
+		cfg.LLM.Provider = tt.provider
+		cfg.LLM.Endpoint = tt.endpoint
+		cfg.SetModel("llama3")
+		if cfg.LLM.Endpoint != tt.endpoint {
+			t.Errorf("provider %q: endpoint = %q after SetModel, want %q", tt.provider, cfg.LLM.Endpoint, tt.endpoint)
+		}
+	}
+
+	cfg := &config.Config{}
+	cfg.SetModel("gpt-5")
+	if cfg.LLM.Endpoint != "https://api.openai.com/v1/chat/completions" {
+		t.Errorf("endpoint = %q, want the OpenAI endpoint for an unset provider", cfg.LLM.Endpoint)
+	}
+}
+
+func TestSetModelAppliesProviderDefaults(t *testing.T) {
+	t.Setenv("ANTHROPIC_API_KEY", "anthropic-key")
+	t.Setenv("GEMINI_API_KEY", "")
+	tests := []struct {
+		model    string
+		endpoint string
+		apiKey   string
+	}{
+		{"claude-sonnet-4-5", "https://api.anthropic.com/v1/messages", "anthropic-key"},
+		{"gemini-2.5-pro", "https://generativelanguage.googleapis.com/v1beta", "configured-key"},
+		{"arn:aws:bedrock:us-east-1::foundation-model/anthropic.claude", "", ""},
+	}
+	for _, tt := range tests {
+		cfg := &config.Config{}
+		cfg.LLM.Endpoint = "https://example.com/v1"
+		cfg.LLM.APIKey = "configured-key"
+		cfg.SetModel(tt.model)
+		if cfg.LLM.Endpoint != tt.endpoint || cfg.LLM.APIKey != tt.apiKey {
+			t.Errorf("SetModel(%q): endpoint %q, key %q, want %q, %q", tt.model, cfg.LLM.Endpoint, cfg.LLM.APIKey, tt.endpoint, tt.apiKey)
+		}
+	}
+}
+
+func TestLoadKeepsDefaultExclude(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"context": {"exclude": ["**/*_mock.go"]}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.Load(path)
+	if want := []string{"**/*_mock.go", "**/testdata/**"}; !slices.Equal(cfg.Context.Exclude, want) {
+		t.Errorf("Exclude = %v, want %v", cfg.Context.Exclude, want)
+	}
+}
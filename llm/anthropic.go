@@ -8,6 +8,8 @@ import (
 	"net/http"
 	"os"
 	"strings"
+
+	"github.com/piqoni/vogte/config"
 )
 
 // Anthropic Messages API structures
@@ -16,6 +18,7 @@ type anthropicChatRequest struct {
 	Messages    []Message `json:"messages"`
 	MaxTokens   int       `json:"max_tokens"`
 	Temperature float64   `json:"temperature,omitempty"`
+	Stream      bool      `json:"stream,omitempty"`
 }
 
 type anthropicContentBlock struct {
@@ -39,22 +42,55 @@ type anthropicChatResponse struct {
 	} `json:"error,omitempty"`
 }
 
-func (c *Client) sendAnthropicRequest(request ChatRequest) (string, error) {
+// defaultAnthropicEndpoint is the Anthropic Messages API.
+const defaultAnthropicEndpoint = "https://api.anthropic.com/v1/messages"
+
+func init() {
+	RegisterProvider("anthropic", newAnthropicProvider, func(cfg *config.Config) bool {
+		return isAnthropicModel(cfg.LLM.Model) || strings.Contains(strings.ToLower(cfg.LLM.Endpoint), "anthropic.com")
+	}, endpointDefaults(defaultAnthropicEndpoint, "ANTHROPIC_API_KEY"))
+}
+
+// anthropicProvider talks to the Anthropic Messages API.
+type anthropicProvider struct {
+	endpoint string
+	apiKey   string
+}
+
+func newAnthropicProvider(cfg *config.Config) (Provider, error) {
 	// Ensure endpoint and API key appropriate for Anthropic
-	endpoint := c.config.LLM.Endpoint
+	endpoint := cfg.LLM.Endpoint
 	if endpoint == "" || strings.Contains(strings.ToLower(endpoint), "openai.com") {
-		endpoint = "https://api.anthropic.com/v1/messages"
+		endpoint = defaultAnthropicEndpoint
 	}
 
 	// Prefer ANTHROPIC_API_KEY if present
 	apiKey := os.Getenv("ANTHROPIC_API_KEY")
 	if apiKey == "" {
-		apiKey = c.config.LLM.APIKey
+		apiKey = cfg.LLM.APIKey
 	}
 	if apiKey == "" {
-		return "", fmt.Errorf("LLM API key not configured (expect ANTHROPIC_API_KEY for Claude models)")
+		return nil, fmt.Errorf("LLM API key not configured (expect ANTHROPIC_API_KEY for Claude models)")
 	}
+	return &anthropicProvider{endpoint: endpoint, apiKey: apiKey}, nil
+}
+
+// Capabilities reports that system prompts cannot be sent as messages; the
+// Messages API takes them in a separate field.
+func (p *anthropicProvider) Capabilities() Capabilities {
+	return Capabilities{SystemMessages: false, Streaming: true}
+}
+
+func (p *anthropicProvider) Chat(request ChatRequest) (Response, error) {
+	return p.send(request, nil)
+}
 
+func (p *anthropicProvider) Stream(request ChatRequest, onText func(string)) (Response, error) {
+	return p.send(request, onText)
+}
+
+// send calls the Messages API, streamed when onText is set.
+func (p *anthropicProvider) send(request ChatRequest, onText func(string)) (Response, error) {
 	maxTokens := request.MaxCompletionTokens
 	if maxTokens == 0 {
 		maxTokens = 1024
@@ -64,46 +100,86 @@ func (c *Client) sendAnthropicRequest(request ChatRequest) (string, error) {
 		Messages:    request.Messages,
 		MaxTokens:   maxTokens,
 		Temperature: request.Temperature,
+		Stream:      onText != nil,
 	}
 
 	jsonData, err := json.Marshal(anthReq)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal anthropic request: %w", err)
+		return Response{}, fmt.Errorf("failed to marshal anthropic request: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", endpoint, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequest("POST", p.endpoint, bytes.NewBuffer(jsonData))
 	if err != nil {
-		return "", fmt.Errorf("failed to create anthropic request: %w", err)
+		return Response{}, fmt.Errorf("failed to create anthropic request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("x-api-key", apiKey)
+	req.Header.Set("x-api-key", p.apiKey)
 	req.Header.Set("anthropic-version", "2023-06-01")
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := httpClients.client(anthReq.Stream).Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to send anthropic request: %w", err)
+		return Response{}, fmt.Errorf("failed to send anthropic request: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if anthReq.Stream && resp.StatusCode == http.StatusOK && isEventStream(resp) {
+		return readAnthropicStream(resp.Body, onText)
+	}
+
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("failed to read anthropic response: %w", err)
+		return Response{}, fmt.Errorf("failed to read anthropic response: %w", err)
 	}
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("Anthropic API request failed with status %d: %s", resp.StatusCode, string(body))
+		return Response{}, newStatusError("Anthropic API request", resp, body)
 	}
 
 	var aResp anthropicChatResponse
 	if err := json.Unmarshal(body, &aResp); err != nil {
-		return "", fmt.Errorf("failed to unmarshal anthropic response: %w", err)
+		return Response{}, fmt.Errorf("failed to unmarshal anthropic response: %w", err)
 	}
 	if aResp.Error != nil {
-		return "", fmt.Errorf("Anthropic API error: %s", aResp.Error.Message)
+		return Response{}, fmt.Errorf("Anthropic API error: %s", aResp.Error.Message)
 	}
 	if len(aResp.Content) == 0 {
-		return "", fmt.Errorf("no content returned from Anthropic")
+		return Response{}, fmt.Errorf("no content returned from Anthropic")
+	}
+	if onText != nil {
+		onText(aResp.Content[0].Text)
+	}
+	return Response{
+		Text:  aResp.Content[0].Text,
+		Usage: Usage{InputTokens: aResp.Usage.InputTokens, OutputTokens: aResp.Usage.OutputTokens},
+	}, nil
+}
+
+// readAnthropicStream collects the text of a streamed Messages response,
+// passing each delta to onText.
+func readAnthropicStream(body io.Reader, onText func(string)) (Response, error) {
+	var text strings.Builder
+	var usage Usage
+	err := readSSE(body, func(event, data string) error {
+		var e anthropicStreamEvent
+		if err := json.Unmarshal([]byte(data), &e); err != nil {
+			return fmt.Errorf("failed to unmarshal anthropic stream event: %w", err)
+		}
+		if e.Error != nil {
+			return anthropicStreamError(e.Error.Type, e.Error.Message)
+		}
+		usage = e.addUsage(usage)
+		if delta := e.text(); delta != "" {
+			text.WriteString(delta)
+			onText(delta)
+		}
+		return nil
+	})
+	if err != nil {
+		return Response{}, fmt.Errorf("failed to read anthropic response stream: %w", err)
+	}
+	if text.Len() == 0 {
+		return Response{}, fmt.Errorf("no content returned from Anthropic")
 	}
-	return aResp.Content[0].Text, nil
+	return Response{Text: text.String(), Usage: usage}, nil
 }
 
 func isAnthropicModel(model string) bool {
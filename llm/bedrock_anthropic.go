@@ -5,10 +5,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+	"github.com/piqoni/vogte/config"
 )
 
 type BedrockRequest struct {
@@ -35,10 +38,44 @@ type BedrockResponse struct {
 	} `json:"usage"`
 }
 
-func (c *Client) sendBedrockRequest(request ChatRequest) (string, error) {
-	cfg, err := config.LoadDefaultConfig(context.TODO())
+func init() {
+	RegisterProvider("bedrock", newBedrockProvider, func(cfg *config.Config) bool {
+		return isBedrockModel(cfg.LLM.Model)
+	}, func(cfg *config.Config) {
+		// Bedrock uses the AWS configuration instead of an endpoint and key.
+		cfg.LLM.Endpoint = ""
+		cfg.LLM.APIKey = ""
+	})
+}
+
+// bedrockProvider invokes Anthropic models on Amazon Bedrock. Credentials come
+// from the default AWS configuration, so no API key or endpoint is needed.
+type bedrockProvider struct{}
+
+func newBedrockProvider(cfg *config.Config) (Provider, error) {
+	return bedrockProvider{}, nil
+}
+
+// Capabilities reports system messages as accepted: they are moved to the
+// system field of the request.
+func (bedrockProvider) Capabilities() Capabilities {
+	return Capabilities{SystemMessages: true, Streaming: true}
+}
+
+func (p bedrockProvider) Chat(request ChatRequest) (Response, error) {
+	return p.send(request, nil)
+}
+
+func (p bedrockProvider) Stream(request ChatRequest, onText func(string)) (Response, error) {
+	return p.send(request, onText)
+}
+
+// send invokes the model, streamed when onText is set.
+func (bedrockProvider) send(request ChatRequest, onText func(string)) (Response, error) {
+	// The client's retry policy applies, so the SDK makes a single attempt.
+	cfg, err := awsconfig.LoadDefaultConfig(context.TODO(), awsconfig.WithRetryMaxAttempts(1))
 	if err != nil {
-		return "", fmt.Errorf("failed to load AWS config: %w", err)
+		return Response{}, fmt.Errorf("failed to load AWS config: %w", err)
 	}
 
 	client := bedrockruntime.NewFromConfig(cfg)
@@ -68,7 +105,11 @@ func (c *Client) sendBedrockRequest(request ChatRequest) (string, error) {
 
 	body, err := json.Marshal(requestBody)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal bedrock request: %w", err)
+		return Response{}, fmt.Errorf("failed to marshal bedrock request: %w", err)
+	}
+
+	if onText != nil {
+		return streamBedrockModel(client, request.Model, body, onText)
 	}
 
 	input := &bedrockruntime.InvokeModelInput{
@@ -78,20 +119,79 @@ func (c *Client) sendBedrockRequest(request ChatRequest) (string, error) {
 
 	response, err := client.InvokeModel(context.TODO(), input)
 	if err != nil {
-		return "", fmt.Errorf("failed to invoke bedrock model: %w", err)
+		return Response{}, fmt.Errorf("failed to invoke bedrock model: %w", err)
 	}
 
 	var bedrockResponse BedrockResponse
 	err = json.Unmarshal(response.Body, &bedrockResponse)
 	if err != nil {
-		return "", fmt.Errorf("failed to unmarshal bedrock response: %w", err)
+		return Response{}, fmt.Errorf("failed to unmarshal bedrock response: %w", err)
 	}
 
 	if len(bedrockResponse.Content) > 0 && bedrockResponse.Content[0].Type == "text" {
-		return bedrockResponse.Content[0].Text, nil
+		return Response{
+			Text:  bedrockResponse.Content[0].Text,
+			Usage: Usage{InputTokens: bedrockResponse.Usage.InputTokens, OutputTokens: bedrockResponse.Usage.OutputTokens},
+		}, nil
 	}
 
-	return "", fmt.Errorf("no text content received from bedrock")
+	return Response{}, fmt.Errorf("no text content received from bedrock")
+}
+
+// streamBedrockModel invokes the model with InvokeModelWithResponseStream,
+// whose chunks carry the events of the Anthropic streaming API.
+func streamBedrockModel(client *bedrockruntime.Client, model string, body []byte, onText func(string)) (Response, error) {
+	output, err := client.InvokeModelWithResponseStream(context.TODO(), &bedrockruntime.InvokeModelWithResponseStreamInput{
+		Body:    body,
+		ModelId: aws.String(model),
+	})
+	if err != nil {
+		return Response{}, fmt.Errorf("failed to invoke bedrock model: %w", err)
+	}
+	stream := output.GetStream()
+	defer stream.Close()
+
+	var text strings.Builder
+	var usage Usage
+	// The SDK reads the stream without a deadline, so a stalled stream is
+	// abandoned here.
+	idle := time.NewTimer(streamIdleTimeout)
+	defer idle.Stop()
+events:
+	for {
+		select {
+		case event, ok := <-stream.Events():
+			if !ok {
+				break events
+			}
+			idle.Reset(streamIdleTimeout)
+			chunk, ok := event.(*types.ResponseStreamMemberChunk)
+			if !ok {
+				continue
+			}
+			var e anthropicStreamEvent
+			if err := json.Unmarshal(chunk.Value.Bytes, &e); err != nil {
+				return Response{}, fmt.Errorf("failed to unmarshal bedrock stream event: %w", err)
+			}
+			if e.Error != nil {
+				return Response{}, fmt.Errorf("bedrock model error: %s", e.Error.Message)
+			}
+			usage = e.addUsage(usage)
+			if delta := e.text(); delta != "" {
+				text.WriteString(delta)
+				onText(delta)
+			}
+		case <-idle.C:
+			return Response{}, &idleTimeoutError{timeout: streamIdleTimeout}
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return Response{}, fmt.Errorf("failed to read bedrock response stream: %w", err)
+	}
+	if text.Len() == 0 {
+		return Response{}, fmt.Errorf("no text content received from bedrock")
+	}
+	return Response{Text: text.String(), Usage: usage}, nil
 }
 
 func isBedrockModel(model string) bool {
@@ -0,0 +1,223 @@
+package llm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/piqoni/vogte/config"
+)
+
+// defaultGeminiEndpoint is the base URL of the Gemini API; model calls are
+// made below it.
+const defaultGeminiEndpoint = "https://generativelanguage.googleapis.com/v1beta"
+
+func init() {
+	RegisterProvider("gemini", newGeminiProvider, func(cfg *config.Config) bool {
+		return isGeminiModel(cfg.LLM.Model)
+	}, endpointDefaults(defaultGeminiEndpoint, "GEMINI_API_KEY"))
+}
+
+// Gemini generateContent API structures
+type geminiPart struct {
+	Text string `json:"text"`
+}
+
+type geminiContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []geminiPart `json:"parts"`
+}
+
+type geminiRequest struct {
+	SystemInstruction *geminiContent         `json:"systemInstruction,omitempty"`
+	Contents          []geminiContent        `json:"contents"`
+	GenerationConfig  geminiGenerationConfig `json:"generationConfig,omitempty"`
+}
+
+type geminiGenerationConfig struct {
+	Temperature     float64 `json:"temperature,omitempty"`
+	MaxOutputTokens int     `json:"maxOutputTokens,omitempty"`
+}
+
+// geminiResponse is the response of generateContent, and each event of
+// streamGenerateContent.
+type geminiResponse struct {
+	Candidates []struct {
+		Content      geminiContent `json:"content"`
+		FinishReason string        `json:"finishReason"`
+	} `json:"candidates"`
+	UsageMetadata struct {
+		PromptTokenCount     int `json:"promptTokenCount"`
+		CandidatesTokenCount int `json:"candidatesTokenCount"`
+	} `json:"usageMetadata"`
+	Error *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Status  string `json:"status"`
+	} `json:"error,omitempty"`
+}
+
+// text joins the text parts of the first candidate.
+func (r geminiResponse) text() string {
+	if len(r.Candidates) == 0 {
+		return ""
+	}
+	var text strings.Builder
+	for _, part := range r.Candidates[0].Content.Parts {
+		text.WriteString(part.Text)
+	}
+	return text.String()
+}
+
+func (r geminiResponse) usage() Usage {
+	return Usage{InputTokens: r.UsageMetadata.PromptTokenCount, OutputTokens: r.UsageMetadata.CandidatesTokenCount}
+}
+
+// geminiProvider talks to the Gemini generateContent API.
+type geminiProvider struct {
+	endpoint string
+	apiKey   string
+}
+
+func newGeminiProvider(cfg *config.Config) (Provider, error) {
+	endpoint := strings.TrimRight(cfg.LLM.Endpoint, "/")
+	if endpoint == "" || strings.Contains(strings.ToLower(endpoint), "openai.com") || strings.Contains(strings.ToLower(endpoint), "anthropic.com") {
+		endpoint = defaultGeminiEndpoint
+	}
+
+	// Prefer GEMINI_API_KEY if present
+	apiKey := os.Getenv("GEMINI_API_KEY")
+	if apiKey == "" {
+		apiKey = cfg.LLM.APIKey
+	}
+	if apiKey == "" {
+		return nil, fmt.Errorf("LLM API key not configured (expect GEMINI_API_KEY for Gemini models)")
+	}
+	return &geminiProvider{endpoint: endpoint, apiKey: apiKey}, nil
+}
+
+// Capabilities reports system messages as accepted: they are sent as the
+// system instruction.
+func (p *geminiProvider) Capabilities() Capabilities {
+	return Capabilities{SystemMessages: true, Streaming: true}
+}
+
+func (p *geminiProvider) Chat(request ChatRequest) (Response, error) {
+	return p.send(request, nil)
+}
+
+func (p *geminiProvider) Stream(request ChatRequest, onText func(string)) (Response, error) {
+	return p.send(request, onText)
+}
+
+// geminiRequestFor maps a chat request to Gemini: "system" messages become
+// the system instruction and "assistant" turns the "model" role.
+func geminiRequestFor(request ChatRequest) geminiRequest {
+	var body geminiRequest
+	var system []string
+	for _, msg := range request.Messages {
+		switch msg.Role {
+		case "system":
+			system = append(system, msg.Content)
+		case "assistant":
+			body.Contents = append(body.Contents, geminiContent{Role: "model", Parts: []geminiPart{{Text: msg.Content}}})
+		default:
+			body.Contents = append(body.Contents, geminiContent{Role: "user", Parts: []geminiPart{{Text: msg.Content}}})
+		}
+	}
+	if len(system) > 0 {
+		body.SystemInstruction = &geminiContent{Parts: []geminiPart{{Text: strings.Join(system, "\n\n")}}}
+	}
+	body.GenerationConfig = geminiGenerationConfig{Temperature: request.Temperature, MaxOutputTokens: request.MaxCompletionTokens}
+	return body
+}
+
+// send calls generateContent, or streamGenerateContent when onText is set.
+func (p *geminiProvider) send(request ChatRequest, onText func(string)) (Response, error) {
+	jsonData, err := json.Marshal(geminiRequestFor(request))
+	if err != nil {
+		return Response{}, fmt.Errorf("failed to marshal gemini request: %w", err)
+	}
+
+	url := p.endpoint + "/models/" + request.Model + ":generateContent"
+	if onText != nil {
+		url = p.endpoint + "/models/" + request.Model + ":streamGenerateContent?alt=sse"
+	}
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return Response{}, fmt.Errorf("failed to create gemini request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-goog-api-key", p.apiKey)
+
+	resp, err := httpClients.client(onText != nil).Do(req)
+	if err != nil {
+		return Response{}, fmt.Errorf("failed to send gemini request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if onText != nil && resp.StatusCode == http.StatusOK {
+		return readGeminiStream(resp.Body, onText)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Response{}, fmt.Errorf("failed to read gemini response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return Response{}, newStatusError("Gemini API request", resp, body)
+	}
+
+	var gResp geminiResponse
+	if err := json.Unmarshal(body, &gResp); err != nil {
+		return Response{}, fmt.Errorf("failed to unmarshal gemini response: %w", err)
+	}
+	if gResp.Error != nil {
+		return Response{}, fmt.Errorf("Gemini API error: %s", gResp.Error.Message)
+	}
+	text := gResp.text()
+	if text == "" {
+		return Response{}, fmt.Errorf("no content returned from Gemini")
+	}
+	return Response{Text: text, Usage: gResp.usage()}, nil
+}
+
+// readGeminiStream collects the text of a streamed response, passing each
+// piece to onText. Every event carries the usage so far.
+func readGeminiStream(body io.Reader, onText func(string)) (Response, error) {
+	var text strings.Builder
+	var usage Usage
+	err := readSSE(body, func(event, data string) error {
+		var chunk geminiResponse
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return fmt.Errorf("failed to unmarshal gemini stream event: %w", err)
+		}
+		if chunk.Error != nil {
+			return fmt.Errorf("Gemini API error: %s", chunk.Error.Message)
+		}
+		if u := chunk.usage(); u != (Usage{}) {
+			usage = u
+		}
+		if delta := chunk.text(); delta != "" {
+			text.WriteString(delta)
+			onText(delta)
+		}
+		return nil
+	})
+	if err != nil {
+		return Response{}, fmt.Errorf("failed to read gemini response stream: %w", err)
+	}
+	if text.Len() == 0 {
+		return Response{}, fmt.Errorf("no content returned from Gemini")
+	}
+	return Response{Text: text.String(), Usage: usage}, nil
+}
+
+func isGeminiModel(model string) bool {
+	m := strings.ToLower(strings.TrimSpace(model))
+	return strings.HasPrefix(m, "gemini-")
+}
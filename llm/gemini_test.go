@@ -0,0 +1,164 @@
+package llm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/piqoni/vogte/config"
+)
+
+func TestGeminiRequestFor(t *testing.T) {
+	body := geminiRequestFor(ChatRequest{
+		Model: "gemini-2.5-pro",
+		Messages: []Message{
+			{Role: "system", Content: "You are terse."},
+			{Role: "user", Content: "Which files?"},
+			{Role: "assistant", Content: "main.go"},
+			{Role: "system", Content: "Answer with a patch."},
+			{Role: "user", Content: "Fix it"},
+		},
+		Temperature:         0.2,
+		MaxCompletionTokens: 1000,
+	})
+
+	if body.SystemInstruction == nil || body.SystemInstruction.Role != "" ||
+		!reflect.DeepEqual(body.SystemInstruction.Parts, []geminiPart{{Text: "You are terse.\n\nAnswer with a patch."}}) {
+		t.Errorf("systemInstruction = %+v, want both system messages joined", body.SystemInstruction)
+	}
+	want := []geminiContent{
+		{Role: "user", Parts: []geminiPart{{Text: "Which files?"}}},
+		{Role: "model", Parts: []geminiPart{{Text: "main.go"}}},
+		{Role: "user", Parts: []geminiPart{{Text: "Fix it"}}},
+	}
+	if !reflect.DeepEqual(body.Contents, want) {
+		t.Errorf("contents = %+v, want %+v", body.Contents, want)
+	}
+	if body.GenerationConfig != (geminiGenerationConfig{Temperature: 0.2, MaxOutputTokens: 1000}) {
+		t.Errorf("generationConfig = %+v", body.GenerationConfig)
+	}
+
+	if body := geminiRequestFor(ChatRequest{Messages: []Message{{Role: "user", Content: "hi"}}}); body.SystemInstruction != nil {
+		t.Errorf("systemInstruction = %+v without system messages, want none", body.SystemInstruction)
+	}
+}
+
+// fakeGemini answers generateContent with one response and
+// streamGenerateContent with one SSE event per piece of reply, and records
+// the requests it gets.
+type fakeGemini struct {
+	reply    []string
+	requests []*http.Request
+	bodies   []geminiRequest
+}
+
+func (f *fakeGemini) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body geminiRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	f.requests = append(f.requests, r)
+	f.bodies = append(f.bodies, body)
+
+	switch r.URL.Path {
+	case "/models/gemini-2.5-pro:generateContent":
+		fmt.Fprintf(w, `{"candidates":[{"content":{"role":"model","parts":[{"text":%q}]},"finishReason":"STOP"}],"usageMetadata":{"promptTokenCount":20,"candidatesTokenCount":5}}`, strings.Join(f.reply, ""))
+	case "/models/gemini-2.5-pro:streamGenerateContent":
+		if r.URL.Query().Get("alt") != "sse" {
+			http.Error(w, "stream without alt=sse", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for i, piece := range f.reply {
+			fmt.Fprintf(w, "data: {\"candidates\":[{\"content\":{\"role\":\"model\",\"parts\":[{\"text\":%q}]}}],\"usageMetadata\":{\"promptTokenCount\":20,\"candidatesTokenCount\":%d}}\n\n", piece, i+1)
+		}
+	default:
+		http.Error(w, `{"error":{"code":404,"message":"model not found","status":"NOT_FOUND"}}`, http.StatusNotFound)
+	}
+}
+
+func newGeminiTestProvider(t *testing.T, endpoint string) Provider {
+	t.Helper()
+	t.Setenv("GEMINI_API_KEY", "")
+	cfg := &config.Config{}
+	cfg.LLM.Model = "gemini-2.5-pro"
+	cfg.LLM.Endpoint = endpoint
+	cfg.LLM.APIKey = "test-key"
+	provider, err := NewProvider(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return provider
+}
+
+var geminiTestRequest = ChatRequest{
+	Model:    "gemini-2.5-pro",
+	Messages: []Message{{Role: "system", Content: "You are terse."}, {Role: "user", Content: "Say hi"}},
+}
+
+func TestGeminiChat(t *testing.T) {
+	server := &fakeGemini{reply: []string{"hi ", "there"}}
+	srv := httptest.NewServer(server)
+	defer srv.Close()
+
+	provider := newGeminiTestProvider(t, srv.URL)
+	response, err := provider.Chat(geminiTestRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Text != "hi there" || response.Usage != (Usage{InputTokens: 20, OutputTokens: 5}) {
+		t.Errorf("Chat = %+v, want the reply with 20 in, 5 out", response)
+	}
+
+	req := server.requests[0]
+	if got := req.Header.Get("x-goog-api-key"); got != "test-key" {
+		t.Errorf("x-goog-api-key = %q, want test-key", got)
+	}
+	if req.URL.Query().Has("key") {
+		t.Errorf("API key sent in the URL: %s", req.URL)
+	}
+	if server.bodies[0].SystemInstruction == nil {
+		t.Error("system message not sent as the system instruction")
+	}
+}
+
+func TestGeminiStream(t *testing.T) {
+	server := &fakeGemini{reply: []string{"one ", "two ", "three"}}
+	srv := httptest.NewServer(server)
+	defer srv.Close()
+
+	var pieces []string
+	provider := newGeminiTestProvider(t, srv.URL)
+	response, err := provider.Stream(geminiTestRequest, func(text string) { pieces = append(pieces, text) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(pieces, "|") != "one |two |three" {
+		t.Errorf("streamed pieces = %q, want one per event", pieces)
+	}
+	// Every event carries the usage so far; the last one counts.
+	if response.Text != "one two three" || response.Usage != (Usage{InputTokens: 20, OutputTokens: 3}) {
+		t.Errorf("Stream = %+v, want the whole reply with the last usage", response)
+	}
+	if got := server.requests[0].Header.Get("x-goog-api-key"); got != "test-key" {
+		t.Errorf("x-goog-api-key = %q, want test-key", got)
+	}
+}
+
+func TestGeminiError(t *testing.T) {
+	srv := httptest.NewServer(&fakeGemini{})
+	defer srv.Close()
+
+	provider := newGeminiTestProvider(t, srv.URL)
+	_, err := provider.Chat(ChatRequest{Model: "gemini-0", Messages: geminiTestRequest.Messages})
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Chat with an unknown model = %v, want a 404 StatusError", err)
+	}
+}
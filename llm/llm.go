@@ -2,34 +2,96 @@ package llm
 
 import (
 	"fmt"
-	"net/http"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/piqoni/vogte/config"
 )
 
 type Client struct {
-	config     *config.Config
-	httpClient *http.Client
-	baseDir    string // baseDir for file operations
+	config  *config.Config
+	baseDir string // baseDir for file operations
+
+	usageMu sync.Mutex
+	usage   Usage // tokens used by all requests so far
+
+	onRetry RetryCallback
 }
 
 func New(cfg *config.Config) *Client {
 	return &Client{
-		config: cfg,
-		httpClient: &http.Client{
-			Timeout: 180 * time.Second,
-		},
+		config:  cfg,
 		baseDir: ".",
 	}
 }
 
+// Usage returns the tokens used by all requests of the client so far.
+func (c *Client) Usage() Usage {
+	c.usageMu.Lock()
+	defer c.usageMu.Unlock()
+	return c.usage
+}
+
+// SetRetryCallback sets the function told about requests that failed and are
+// about to be retried.
+func (c *Client) SetRetryCallback(callback RetryCallback) {
+	c.onRetry = callback
+}
+
+// acceptsSystemMessages reports whether the selected provider takes "system"
+// role messages.
+func (c *Client) acceptsSystemMessages() bool {
+	provider, err := NewProvider(c.config)
+	return err == nil && provider.Capabilities().SystemMessages
+}
+
+// File selection modes for the "file_selection" llm setting.
+const (
+	FileSelectionModel = "model" // always ask the model, without hints
+	FileSelectionHint  = "hint"  // ask the model, passing the locally ranked files
+	FileSelectionLocal = "local" // use the locally ranked files when confident
+)
+
+// FileHints are the files a local search ranked as most relevant to the task,
+// best first.
+type FileHints struct {
+	Files []string
+	// Confident is set when the files are likely enough to suffice that the
+	// model need not be asked for them.
+	Confident bool
+}
+
+// fileSelection returns the configured file selection mode, defaulting to
+// passing the hints to the model.
+func (c *Client) fileSelection() string {
+	switch mode := c.config.LLM.FileSelection; mode {
+	case FileSelectionModel, FileSelectionLocal:
+		return mode
+	}
+	return FileSelectionHint
+}
+
+// UsesFileHints reports whether the files ranked by a local search are used,
+// so callers can skip building the search index when they are not.
+func (c *Client) UsesFileHints() bool {
+	return c.fileSelection() != FileSelectionModel
+}
+
+// SkipsFileSelection reports whether SendMessage uses the hinted files as they
+// are instead of asking the model which files it needs.
+func (c *Client) SkipsFileSelection(hints FileHints) bool {
+	return c.fileSelection() == FileSelectionLocal && hints.Confident && len(hints.Files) > 0
+}
+
 // SendMessage sends a message to the LLM using a two-step approach:
-// 1. First asks which files are needed
+// 1. First asks which files are needed, unless the hints are used as they are
 // 2. Then sends full file contents for patching
-func (c *Client) SendMessage(userMessage, projectStructure, mode string) (string, error) {
+//
+// When onText is set, the answer of the second step is streamed to it as it
+// arrives. The full answer is returned either way.
+func (c *Client) SendMessage(userMessage, projectStructure, mode string, hints FileHints, onText func(string)) (string, error) {
 	// if mode == "ASK" {
 	// 	log.Print("SIMPLE ASK PATH")
 	// 	log.Printf("userMessage: %s, projectStructure: %s", userMessage, projectStructure)
@@ -37,9 +99,17 @@ func (c *Client) SendMessage(userMessage, projectStructure, mode string) (string
 	// }
 
 	// Step 1: Ask LLM which files it needs
-	fileList, err := c.askForRequiredFiles(userMessage, projectStructure)
-	if err != nil {
-		return "", fmt.Errorf("error getting required files: %w", err)
+	fileList := hints.Files
+	if !c.SkipsFileSelection(hints) {
+		var shortlist []string
+		if c.fileSelection() == FileSelectionHint {
+			shortlist = hints.Files
+		}
+		var err error
+		fileList, err = c.askForRequiredFiles(userMessage, projectStructure, shortlist)
+		if err != nil {
+			return "", fmt.Errorf("error getting required files: %w", err)
+		}
 	}
 
 	// TODO: decide what to do when no list of files is returned
@@ -53,19 +123,27 @@ func (c *Client) SendMessage(userMessage, projectStructure, mode string) (string
 	}
 
 	// Step 3: Request patch with full file contents
-	return c.requestPatch(userMessage, fullFiles)
+	return c.requestPatch(userMessage, fullFiles, onText)
 }
 
-// askForRequiredFiles asks the LLM which files it needs to see in full
-func (c *Client) askForRequiredFiles(task, blueprint string) ([]string, error) {
+// askForRequiredFiles asks the LLM which files it needs to see in full. The
+// shortlist, when given, is offered as a starting point.
+func (c *Client) askForRequiredFiles(task, blueprint string, shortlist []string) ([]string, error) {
+	hint := ""
+	if len(shortlist) > 0 {
+		hint = "\nA local search of identifiers, comments and strings ranked these files as the most relevant, best first: " + strings.Join(shortlist, ",") + ". Treat them as a hint, not a constraint.\n"
+	}
 	prompt := fmt.Sprintf(`Given this coding task: "%s"
 
 And this project structure showing all structs, interfaces, and function signatures:
 %s
 
 Please respond with ONLY a comma-separated list of the specific files you need to see in full to complete this task. Do not include any explanations, just the file paths.
-
-Example response: main.go,utils/helper.go,models/user.go`, task, blueprint)
+Files marked "generated by ... DO NOT EDIT" must never be patched; ask for the files they are generated from instead.
+Use the "imports" and "callers" sections, when present, to judge which other files a change affects.
+If the structure starts with a "scope:" line, the user pinned those packages: choose files inside them, and files marked "(dependency)" only when the task requires it.
+%s
+Example response: main.go,utils/helper.go,models/user.go`, task, blueprint, hint)
 
 	messages := []Message{
 
@@ -75,7 +153,7 @@ Example response: main.go,utils/helper.go,models/user.go`, task, blueprint)
 		},
 	}
 
-	if !isAnthropicModel(c.config.LLM.Model) {
+	if c.acceptsSystemMessages() {
 		messages = append(messages, Message{
 			Role:    "system",
 			Content: "You are a precise coding assistant. Always follow instructions exactly.",
@@ -139,7 +217,7 @@ func (c *Client) getFileContents(fileList []string) (map[string]string, error) {
 }
 
 // requestPatch asks the LLM to generate a patch for the task with full file contents
-func (c *Client) requestPatch(task string, fileContents map[string]string) (string, error) {
+func (c *Client) requestPatch(task string, fileContents map[string]string, onText func(string)) (string, error) {
 	// Build the prompt with file contents
 	var promptBuilder strings.Builder
 	promptBuilder.WriteString(fmt.Sprintf(`Task: %s
@@ -188,6 +266,7 @@ CRITICAL REQUIREMENTS:
 9. Match EXACT indentation and whitespace from the original file
 10. The @@ line should be simple: either just "func functionName() {" or a simple context
 11. If it's a method, just use the method name: "func MethodName() {"
+12. Never patch generated files (with a "Code generated ... DO NOT EDIT." header); change their source (.proto, SQL queries, go:generate inputs) instead
 
 Working example:
 *** Begin Patch ***
@@ -213,7 +292,7 @@ If the function signature is complex, try using a simpler context or just the li
 		// MaxCompletionTokens: 4000,
 	}
 
-	return c.sendChatRequest(request)
+	return c.streamChatRequest(request, onText)
 }
 
 // func (c *Client) sendSimpleMessage(userMessage, projectStructure string) (string, error) {
@@ -251,43 +330,105 @@ If the function signature is complex, try using a simpler context or just the li
 // }
 
 func (c *Client) sendChatRequest(request ChatRequest) (string, error) {
-	if err := c.ValidateConfig(); err != nil {
+	return c.streamChatRequest(request, nil)
+}
+
+// streamChatRequest sends the request to the configured provider, streaming
+// the response to onText when it is set, and returns the full response text.
+// Transient failures are retried with the same request, unless part of the
+// response was already passed to onText.
+func (c *Client) streamChatRequest(request ChatRequest, onText func(string)) (string, error) {
+	provider, err := NewProvider(c.config)
+	if err != nil {
 		return "", err
 	}
-	if isBedrockModel(request.Model) {
-		return c.sendBedrockRequest(request)
-	}
-	if isAnthropicModel(request.Model) || strings.Contains(strings.ToLower(c.config.LLM.Endpoint), "anthropic.com") {
-		return c.sendAnthropicRequest(request)
+	policy := c.retryPolicy()
+	var response Response
+	for attempt := 1; ; attempt++ {
+		streamed := false
+		if onText != nil {
+			response, err = provider.Stream(request, func(text string) {
+				streamed = true
+				onText(text)
+			})
+		} else {
+			response, err = provider.Chat(request)
+		}
+		if err == nil {
+			break
+		}
+		delay, retry := policy.delay(attempt, err)
+		if !retry || streamed {
+			return "", err
+		}
+		if c.onRetry != nil {
+			c.onRetry(attempt+1, policy.maxAttempts, delay, err)
+		}
+		time.Sleep(delay)
 	}
-	return c.sendOpenAIRequest(request)
+
+	c.usageMu.Lock()
+	c.usage = c.usage.Add(response.Usage)
+	c.usageMu.Unlock()
+	return response.Text, nil
 }
 
+// ValidateConfig checks that the configuration selects a provider with what it
+// needs, such as an API key.
 func (c *Client) ValidateConfig() error {
-	if c.config.LLM.Model == "" {
-		return fmt.Errorf("model is required")
-	}
+	_, err := NewProvider(c.config)
+	return err
+}
 
-	// Bedrock models don't need API keys or endpoints
-	if isBedrockModel(c.config.LLM.Model) {
-		return nil
-	}
+// contextWindows lists known context window sizes in tokens by model name
+// prefix. More specific prefixes must come first.
+var contextWindows = []struct {
+	prefix string
+	tokens int
+}{
+	{"gpt-5", 400000},
+	{"gpt-4.1", 1000000},
+	{"gpt-4o", 128000},
+	{"gpt-4-turbo", 128000},
+	{"gpt-4", 8192},
+	{"o1", 200000},
+	{"o3", 200000},
+	{"o4", 200000},
+	{"claude-", 200000},
+	{"gemini-", 1000000},
+}
 
-	if c.config.LLM.APIKey == "" {
-		return fmt.Errorf("API key is required")
-	}
+// defaultContextWindow is assumed for models not listed in contextWindows.
+const defaultContextWindow = 128000
 
-	if c.config.LLM.Endpoint == "" {
-		return fmt.Errorf("API endpoint is required")
+// ContextWindow returns the context window size in tokens of the given model.
+func ContextWindow(model string) int {
+	m := strings.ToLower(strings.TrimSpace(model))
+	if isBedrockModel(m) {
+		return 200000 // Bedrock is only used with Anthropic models
 	}
+	for _, w := range contextWindows {
+		if strings.HasPrefix(m, w.prefix) {
+			return w.tokens
+		}
+	}
+	return defaultContextWindow
+}
 
-	return nil
+// ContextBudget returns how many tokens the project blueprint may use. Unless
+// configured explicitly, half of the model's context window is given to the
+// blueprint and the rest is left for the prompt and the response.
+func (c *Client) ContextBudget() int {
+	if c.config.Context.MaxTokens > 0 {
+		return c.config.Context.MaxTokens
+	}
+	return ContextWindow(c.config.LLM.Model) / 2
 }
 
 // ReviewDiff asks the LLM to review a diff and point out potential issues.
 func (c *Client) ReviewDiff(diff, description string) (string, error) {
-	if c.config.LLM.APIKey == "" {
-		return "", fmt.Errorf("LLM API key not configured")
+	if err := c.ValidateConfig(); err != nil {
+		return "", err
 	}
 
 	desc := strings.TrimSpace(description)
@@ -331,7 +472,7 @@ Verdict: Ready / Needs attention`, desc, diff)
 		},
 	}
 
-	if !isAnthropicModel(c.config.LLM.Model) {
+	if c.acceptsSystemMessages() {
 		messages = append([]Message{{Role: "system", Content: systemMsg}}, messages...)
 	}
 
@@ -0,0 +1,274 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/piqoni/vogte/config"
+	"github.com/piqoni/vogte/parser"
+)
+
+// defaultLocalEndpoint is where Ollama listens by default.
+const defaultLocalEndpoint = "http://localhost:11434"
+
+// Context sizes requested from Ollama: room for the response on top of the
+// prompt, rounded up to numCtxStep and never below minNumCtx.
+const (
+	responseReserve = 4096
+	numCtxStep      = 2048
+	minNumCtx       = 8192
+)
+
+func init() {
+	RegisterProvider("local", newLocalProvider, func(cfg *config.Config) bool {
+		return strings.Contains(cfg.LLM.Endpoint, ":11434")
+	}, nil) // the configured endpoint is kept, so nothing is sent elsewhere
+}
+
+// localProvider talks to a model server on the machine or the local network,
+// without an API key: Ollama through its /api/chat protocol, or any
+// OpenAI-compatible server when the endpoint has a /v1 path.
+type localProvider struct {
+	base   string // server URL without the API path
+	openAI *openAIProvider
+}
+
+// availableModels remembers the server and model pairs found available, so the
+// server is asked for its models once rather than on every request.
+var availableModels sync.Map
+
+func newLocalProvider(cfg *config.Config) (Provider, error) {
+	endpoint := strings.TrimRight(cfg.LLM.Endpoint, "/")
+	if lower := strings.ToLower(endpoint); endpoint == "" || strings.Contains(lower, "openai.com") || strings.Contains(lower, "anthropic.com") || strings.Contains(lower, "googleapis.com") {
+		endpoint = defaultLocalEndpoint
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid local endpoint %q", cfg.LLM.Endpoint)
+	}
+
+	p := &localProvider{base: u.Scheme + "://" + u.Host}
+	if path, _, ok := strings.Cut(u.Path, "/v1"); ok {
+		// An OpenAI-compatible server such as llama.cpp, vLLM or LM Studio.
+		p.base += path + "/v1"
+		p.openAI = &openAIProvider{endpoint: p.base + "/chat/completions", apiKey: cfg.LLM.APIKey}
+		// Such servers often serve one model under whatever name is asked
+		// for, so the model is only checked when a request fails.
+		return p, nil
+	}
+	if err := p.checkModel(cfg.LLM.Model); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *localProvider) Capabilities() Capabilities {
+	return Capabilities{SystemMessages: true, Streaming: true}
+}
+
+func (p *localProvider) Chat(request ChatRequest) (Response, error) {
+	return p.send(request, nil)
+}
+
+func (p *localProvider) Stream(request ChatRequest, onText func(string)) (Response, error) {
+	return p.send(request, onText)
+}
+
+// Models lists the models the server has available.
+func (p *localProvider) Models() ([]string, error) {
+	if p.openAI != nil {
+		var list struct {
+			Data []struct {
+				ID string `json:"id"`
+			} `json:"data"`
+		}
+		if err := p.get("/models", &list); err != nil {
+			return nil, err
+		}
+		var models []string
+		for _, m := range list.Data {
+			models = append(models, m.ID)
+		}
+		return models, nil
+	}
+
+	var tags struct {
+		Models []struct {
+			Name string `json:"name"`
+		} `json:"models"`
+	}
+	if err := p.get("/api/tags", &tags); err != nil {
+		return nil, err
+	}
+	var models []string
+	for _, m := range tags.Models {
+		models = append(models, m.Name)
+	}
+	return models, nil
+}
+
+func (p *localProvider) get(path string, v any) error {
+	resp, err := httpClients.client(false).Get(p.base + path)
+	if err != nil {
+		return fmt.Errorf("failed to reach local model server: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read local model server response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return newStatusError("local model server request", resp, body)
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to unmarshal local model server response: %w", err)
+	}
+	return nil
+}
+
+// checkModel fails with the list of available models when the server does not
+// have the requested one. Ollama also accepts names without the ":latest" tag.
+func (p *localProvider) checkModel(model string) error {
+	key := p.base + "\x00" + model
+	if _, ok := availableModels.Load(key); ok {
+		return nil
+	}
+	models, err := p.Models()
+	if err != nil {
+		return err
+	}
+	for _, m := range models {
+		if m == model || m == model+":latest" {
+			availableModels.Store(key, true)
+			return nil
+		}
+	}
+	if len(models) == 0 {
+		return fmt.Errorf("model %q is not available on %s, which has no models", model, p.base)
+	}
+	return fmt.Errorf("model %q is not available on %s; available models: %s", model, p.base, strings.Join(models, ", "))
+}
+
+// ollamaChatRequest is the request body of Ollama's /api/chat.
+type ollamaChatRequest struct {
+	Model    string        `json:"model"`
+	Messages []Message     `json:"messages"`
+	Stream   bool          `json:"stream"`
+	Options  ollamaOptions `json:"options"`
+}
+
+type ollamaOptions struct {
+	NumCtx      int     `json:"num_ctx"`
+	Temperature float64 `json:"temperature,omitempty"`
+}
+
+// ollamaChatResponse is the response of /api/chat, or one line of it when
+// streamed. The token counts arrive once done is set.
+type ollamaChatResponse struct {
+	Message struct {
+		Content string `json:"content"`
+	} `json:"message"`
+	Done            bool   `json:"done"`
+	PromptEvalCount int    `json:"prompt_eval_count"`
+	EvalCount       int    `json:"eval_count"`
+	Error           string `json:"error"`
+}
+
+// send sends the request, streamed when onText is set.
+func (p *localProvider) send(request ChatRequest, onText func(string)) (Response, error) {
+	if p.openAI != nil {
+		response, err := p.openAI.send(request, onText)
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) && statusErr.StatusCode < 500 {
+			if modelErr := p.checkModel(request.Model); modelErr != nil {
+				return Response{}, fmt.Errorf("%w (%v)", err, modelErr)
+			}
+		}
+		return response, err
+	}
+
+	ollamaReq := ollamaChatRequest{
+		Model:    request.Model,
+		Messages: request.Messages,
+		Stream:   onText != nil,
+		Options:  ollamaOptions{NumCtx: numCtx(request.Messages), Temperature: request.Temperature},
+	}
+	jsonData, err := json.Marshal(ollamaReq)
+	if err != nil {
+		return Response{}, fmt.Errorf("failed to marshal local request: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", p.base+"/api/chat", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return Response{}, fmt.Errorf("failed to create local request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpClients.client(ollamaReq.Stream).Do(req)
+	if err != nil {
+		return Response{}, fmt.Errorf("failed to send local request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return Response{}, newStatusError("local model request", resp, body)
+	}
+
+	// A streamed response is one JSON object per line; a plain one is a
+	// single object.
+	var text strings.Builder
+	var usage Usage
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), maxEventSize)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var chunk ollamaChatResponse
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			return Response{}, fmt.Errorf("failed to unmarshal local response: %w", err)
+		}
+		if chunk.Error != "" {
+			return Response{}, fmt.Errorf("local model error: %s", chunk.Error)
+		}
+		if chunk.Message.Content != "" {
+			text.WriteString(chunk.Message.Content)
+			if onText != nil {
+				onText(chunk.Message.Content)
+			}
+		}
+		if chunk.Done {
+			usage = Usage{InputTokens: chunk.PromptEvalCount, OutputTokens: chunk.EvalCount}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Response{}, fmt.Errorf("failed to read local response: %w", err)
+	}
+	if text.Len() == 0 {
+		return Response{}, fmt.Errorf("no content returned from the local model")
+	}
+	return Response{Text: text.String(), Usage: usage}, nil
+}
+
+// numCtx sizes the context window Ollama allocates to fit the prompt, which
+// is mostly the project blueprint, plus the response. The prompt is counted
+// the way the blueprint budget is. Ollama otherwise uses a small default and
+// silently drops the start of long prompts.
+func numCtx(messages []Message) int {
+	tokens := 0
+	for _, msg := range messages {
+		tokens += parser.EstimateTokens(msg.Content)
+	}
+	size := (tokens + responseReserve + numCtxStep - 1) / numCtxStep * numCtxStep
+	return max(size, minNumCtx)
+}
@@ -0,0 +1,199 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/piqoni/vogte/config"
+)
+
+// fakeOllama is an Ollama server with the given models that answers /api/chat
+// with reply, split into one NDJSON line per piece when streaming.
+type fakeOllama struct {
+	models []string
+	reply  []string
+
+	mu          sync.Mutex
+	tagRequests int
+	chats       []ollamaChatRequest
+}
+
+func (f *fakeOllama) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.URL.Path {
+	case "/api/tags":
+		f.tagRequests++
+		var tags struct {
+			Models []map[string]string `json:"models"`
+		}
+		for _, name := range f.models {
+			tags.Models = append(tags.Models, map[string]string{"name": name})
+		}
+		json.NewEncoder(w).Encode(tags)
+	case "/api/chat":
+		var req ollamaChatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.chats = append(f.chats, req)
+		if !req.Stream {
+			fmt.Fprintf(w, `{"message":{"content":%q},"done":true,"prompt_eval_count":12,"eval_count":3}`, strings.Join(f.reply, ""))
+			return
+		}
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		for _, piece := range f.reply {
+			fmt.Fprintf(w, "{\"message\":{\"content\":%q},\"done\":false}\n", piece)
+		}
+		fmt.Fprint(w, `{"message":{"content":""},"done":true,"prompt_eval_count":12,"eval_count":3}`+"\n")
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func localConfig(endpoint, model string) *config.Config {
+	cfg := &config.Config{}
+	cfg.LLM.Provider = "local"
+	cfg.LLM.Endpoint = endpoint
+	cfg.LLM.Model = model
+	return cfg
+}
+
+func newTestProvider(t *testing.T, cfg *config.Config) Provider {
+	t.Helper()
+	provider, err := NewProvider(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return provider
+}
+
+var testRequest = ChatRequest{
+	Model:    "llama3",
+	Messages: []Message{{Role: "system", Content: "You are terse."}, {Role: "user", Content: "Say hi"}},
+}
+
+func TestLocalChat(t *testing.T) {
+	server := &fakeOllama{models: []string{"llama3:latest"}, reply: []string{"hi ", "there"}}
+	srv := httptest.NewServer(server)
+	defer srv.Close()
+
+	provider := newTestProvider(t, localConfig(srv.URL, "llama3"))
+	response, err := provider.Chat(testRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Text != "hi there" || response.Usage != (Usage{InputTokens: 12, OutputTokens: 3}) {
+		t.Errorf("Chat = %+v, want the reply with 12 in, 3 out", response)
+	}
+
+	got := server.chats[0]
+	if got.Model != "llama3" || got.Stream || len(got.Messages) != 2 || got.Messages[0].Role != "system" {
+		t.Errorf("request = %+v, want the model and messages unchanged, not streamed", got)
+	}
+	if got.Options.NumCtx != numCtx(testRequest.Messages) {
+		t.Errorf("num_ctx = %d, want %d", got.Options.NumCtx, numCtx(testRequest.Messages))
+	}
+}
+
+func TestLocalStream(t *testing.T) {
+	server := &fakeOllama{models: []string{"llama3"}, reply: []string{"one ", "two ", "three"}}
+	srv := httptest.NewServer(server)
+	defer srv.Close()
+
+	var pieces []string
+	provider := newTestProvider(t, localConfig(srv.URL, "llama3"))
+	response, err := provider.Stream(testRequest, func(text string) { pieces = append(pieces, text) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(pieces, "|") != "one |two |three" {
+		t.Errorf("streamed pieces = %q, want one line each", pieces)
+	}
+	if response.Text != "one two three" || response.Usage != (Usage{InputTokens: 12, OutputTokens: 3}) {
+		t.Errorf("Stream = %+v, want the whole reply with the final usage", response)
+	}
+	if !server.chats[0].Stream {
+		t.Error("request was not streamed")
+	}
+}
+
+func TestLocalModelDiscovery(t *testing.T) {
+	server := &fakeOllama{models: []string{"llama3:latest", "qwen2.5-coder:14b"}, reply: []string{"ok"}}
+	srv := httptest.NewServer(server)
+	defer srv.Close()
+
+	_, err := NewProvider(localConfig(srv.URL, "mistral"))
+	if err == nil || !strings.Contains(err.Error(), "llama3:latest, qwen2.5-coder:14b") {
+		t.Errorf("NewProvider with a missing model = %v, want an error listing the available models", err)
+	}
+
+	// The model is found once and then remembered, also across retries.
+	server.tagRequests = 0
+	for range 3 {
+		provider := newTestProvider(t, localConfig(srv.URL, "qwen2.5-coder:14b"))
+		if _, err := provider.Chat(testRequest); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if server.tagRequests != 1 {
+		t.Errorf("models listed %d times, want once", server.tagRequests)
+	}
+}
+
+func TestLocalOpenAICompatible(t *testing.T) {
+	fail := false
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/models", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":[{"id":"served-model.gguf"}]}`)
+	})
+	mux.HandleFunc("/v1/chat/completions", func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			http.Error(w, `{"error":{"message":"model not found"}}`, http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"hello"}}],"usage":{"prompt_tokens":4,"completion_tokens":1}}`)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	// A name the server does not list is not an error up front.
+	provider := newTestProvider(t, localConfig(srv.URL+"/v1", "llama"))
+	response, err := provider.Chat(ChatRequest{Model: "llama", Messages: testRequest.Messages})
+	if err != nil || response.Text != "hello" {
+		t.Fatalf("Chat = %+v, %v, want hello", response, err)
+	}
+
+	// A failed request mentions the models the server lists.
+	fail = true
+	_, err = provider.Chat(ChatRequest{Model: "llama", Messages: testRequest.Messages})
+	if err == nil || !strings.Contains(err.Error(), "served-model.gguf") {
+		t.Errorf("failed Chat = %v, want the available models mentioned", err)
+	}
+}
+
+func TestNumCtx(t *testing.T) {
+	tests := []struct {
+		chars int
+		want  int
+	}{
+		{0, minNumCtx},
+		{4 * 1000, minNumCtx},
+		// 25000 tokens plus the reserve, rounded up to the step.
+		{4 * 25000, 30720},
+		{4 * (30720 - responseReserve), 30720},
+		{4*(30720-responseReserve) + 1, 30720 + numCtxStep},
+	}
+	for _, tt := range tests {
+		messages := []Message{{Role: "user", Content: strings.Repeat("x", tt.chars)}}
+		if got := numCtx(messages); got != tt.want {
+			t.Errorf("numCtx(%d chars) = %d, want %d", tt.chars, got, tt.want)
+		}
+	}
+}
@@ -6,6 +6,9 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
+
+	"github.com/piqoni/vogte/config"
 )
 
 // Message represents a chat message
@@ -20,6 +23,7 @@ type ChatRequest struct {
 	Messages            []Message `json:"messages"`
 	Temperature         float64   `json:"temperature,omitempty"`
 	MaxCompletionTokens int       `json:"max_completion_tokens,omitempty"`
+	Stream              bool      `json:"stream,omitempty"`
 }
 
 // ChatResponse represents the response from chat completion
@@ -48,53 +52,167 @@ type ChatResponse struct {
 	} `json:"error,omitempty"`
 }
 
-// OpenAI chat.completions-compatible request
-func (c *Client) sendOpenAIRequest(request ChatRequest) (string, error) {
-	jsonData, err := json.Marshal(request)
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
-	}
+// chatStreamChunk is a server-sent event of a streamed chat completion. Usage
+// arrives in a final chunk without choices.
+type chatStreamChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+	} `json:"choices"`
+	Usage *struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage,omitempty"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// openAIStreamOptions asks for the usage of a streamed completion.
+type openAIStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+// openAIRequest is ChatRequest as sent to the chat completions API.
+type openAIRequest struct {
+	ChatRequest
+	StreamOptions *openAIStreamOptions `json:"stream_options,omitempty"`
+}
+
+// defaultOpenAIEndpoint is the OpenAI chat completions API.
+const defaultOpenAIEndpoint = "https://api.openai.com/v1/chat/completions"
+
+func init() {
+	RegisterProvider("openai", newOpenAIProvider, nil, endpointDefaults(defaultOpenAIEndpoint, "OPENAI_API_KEY"))
+}
+
+// openAIProvider talks to the OpenAI chat completions API or a compatible
+// server. It serves every model no other provider claims.
+type openAIProvider struct {
+	endpoint string
+	apiKey   string
+}
 
-	endpoint := c.config.LLM.Endpoint
+func newOpenAIProvider(cfg *config.Config) (Provider, error) {
+	if cfg.LLM.APIKey == "" {
+		return nil, fmt.Errorf("API key is required")
+	}
+	endpoint := cfg.LLM.Endpoint
 	if endpoint == "" {
-		endpoint = "https://api.openai.com/v1/chat/completions"
+		endpoint = defaultOpenAIEndpoint
 	}
+	return &openAIProvider{endpoint: endpoint, apiKey: cfg.LLM.APIKey}, nil
+}
+
+func (p *openAIProvider) Capabilities() Capabilities {
+	return Capabilities{SystemMessages: true, Streaming: true}
+}
+
+func (p *openAIProvider) Chat(request ChatRequest) (Response, error) {
+	return p.send(request, nil)
+}
 
-	req, err := http.NewRequest("POST", endpoint, bytes.NewBuffer(jsonData))
+func (p *openAIProvider) Stream(request ChatRequest, onText func(string)) (Response, error) {
+	return p.send(request, onText)
+}
+
+// send posts a chat completions request, streamed when onText is set.
+func (p *openAIProvider) send(request ChatRequest, onText func(string)) (Response, error) {
+	body := openAIRequest{ChatRequest: request}
+	body.Stream = onText != nil
+	if body.Stream {
+		body.StreamOptions = &openAIStreamOptions{IncludeUsage: true}
+	}
+	jsonData, err := json.Marshal(body)
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return Response{}, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", p.endpoint, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return Response{}, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.config.LLM.APIKey)
+	// Local OpenAI-compatible servers may not need a key.
+	if p.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := httpClients.client(body.Stream).Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to send request: %w", err)
+		return Response{}, fmt.Errorf("failed to send request: %w", err)
 	}
 	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	if body.Stream && resp.StatusCode == http.StatusOK && isEventStream(resp) {
+		return readOpenAIStream(resp.Body, onText)
+	}
+
+	data, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
+		return Response{}, fmt.Errorf("failed to read response: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+		return Response{}, newStatusError("API request", resp, data)
 	}
 
 	var response ChatResponse
-	if err := json.Unmarshal(body, &response); err != nil {
-		return "", fmt.Errorf("failed to unmarshal response: %w", err)
+	if err := json.Unmarshal(data, &response); err != nil {
+		return Response{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
 	if response.Error != nil {
-		return "", fmt.Errorf("API error: %s", response.Error.Message)
+		return Response{}, fmt.Errorf("API error: %s", response.Error.Message)
 	}
 
 	if len(response.Choices) == 0 {
-		return "", fmt.Errorf("no response choices received")
+		return Response{}, fmt.Errorf("no response choices received")
+	}
+
+	text := response.Choices[0].Message.Content
+	// Servers that do not support streaming answer with plain JSON.
+	if onText != nil {
+		onText(text)
 	}
+	return Response{
+		Text:  text,
+		Usage: Usage{InputTokens: response.Usage.PromptTokens, OutputTokens: response.Usage.CompletionTokens},
+	}, nil
+}
 
-	return response.Choices[0].Message.Content, nil
+// readOpenAIStream collects the text of a streamed chat completion, passing
+// each delta to onText.
+func readOpenAIStream(body io.Reader, onText func(string)) (Response, error) {
+	var text strings.Builder
+	var usage Usage
+	err := readSSE(body, func(event, data string) error {
+		if data == "[DONE]" {
+			return nil
+		}
+		var chunk chatStreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return fmt.Errorf("failed to unmarshal stream chunk: %w", err)
+		}
+		if chunk.Error != nil {
+			return fmt.Errorf("API error: %s", chunk.Error.Message)
+		}
+		if chunk.Usage != nil {
+			usage = Usage{InputTokens: chunk.Usage.PromptTokens, OutputTokens: chunk.Usage.CompletionTokens}
+		}
+		if len(chunk.Choices) > 0 && chunk.Choices[0].Delta.Content != "" {
+			text.WriteString(chunk.Choices[0].Delta.Content)
+			onText(chunk.Choices[0].Delta.Content)
+		}
+		return nil
+	})
+	if err != nil {
+		return Response{}, fmt.Errorf("failed to read response stream: %w", err)
+	}
+	if text.Len() == 0 {
+		return Response{}, fmt.Errorf("no response choices received")
+	}
+	return Response{Text: text.String(), Usage: usage}, nil
 }
@@ -0,0 +1,276 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/piqoni/vogte/config"
+)
+
+// requestTimeout bounds a whole request, or only the wait for the response
+// headers when the response is streamed.
+const requestTimeout = 180 * time.Second
+
+// streamIdleTimeout bounds the wait for the next data of a streamed response,
+// so a server that stalls partway fails the request instead of hanging it. It
+// is a variable for tests.
+var streamIdleTimeout = 60 * time.Second
+
+// Provider is an LLM backend that vogte sends chat requests to.
+type Provider interface {
+	// Chat sends the request and returns the whole response.
+	Chat(request ChatRequest) (Response, error)
+	// Stream sends the request and passes the response text to onText as it
+	// arrives. The returned response holds the whole text.
+	Stream(request ChatRequest, onText func(string)) (Response, error)
+	Capabilities() Capabilities
+}
+
+// Response is the answer to a chat request.
+type Response struct {
+	Text  string
+	Usage Usage
+}
+
+// Usage is the number of tokens a request consumed, as reported by the
+// provider. Providers that do not report usage leave it zero.
+type Usage struct {
+	InputTokens  int
+	OutputTokens int
+}
+
+// Add returns the sum of two usages.
+func (u Usage) Add(other Usage) Usage {
+	return Usage{InputTokens: u.InputTokens + other.InputTokens, OutputTokens: u.OutputTokens + other.OutputTokens}
+}
+
+// Capabilities describe how requests must be shaped for a provider.
+type Capabilities struct {
+	// SystemMessages is set when "system" role messages are accepted among
+	// the other messages.
+	SystemMessages bool
+	// Streaming is set when Stream delivers the text incrementally rather
+	// than all at once.
+	Streaming bool
+}
+
+func init() {
+	config.SetProviderDefaults(applyDefaults)
+}
+
+// ProviderFactory builds a provider from the configuration, returning an
+// error when the configuration lacks what the provider needs, such as an API
+// key.
+type ProviderFactory func(cfg *config.Config) (Provider, error)
+
+type registration struct {
+	name     string
+	factory  ProviderFactory
+	matches  func(cfg *config.Config) bool
+	defaults func(cfg *config.Config)
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   []registration
+)
+
+// defaultProvider serves models no registered provider claims.
+const defaultProvider = "openai"
+
+// RegisterProvider makes a provider available under name, the value of the
+// "provider" llm setting. When the setting is empty, the first registered
+// provider whose matches function accepts the configuration is used, so
+// matches can claim model names such as "claude-*"; it may be nil. defaults
+// sets the endpoint and API key the provider starts from when it is selected,
+// see config.ApplyProviderByModel; it may be nil to keep the configured ones.
+// Registering a name again replaces the earlier provider.
+func RegisterProvider(name string, factory ProviderFactory, matches func(cfg *config.Config) bool, defaults func(cfg *config.Config)) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	r := registration{name: name, factory: factory, matches: matches, defaults: defaults}
+	for i := range registry {
+		if registry[i].name == name {
+			registry[i] = r
+			return
+		}
+	}
+	registry = append(registry, r)
+}
+
+// endpointDefaults returns a defaults function that sets endpoint and, when
+// the environment variable keyEnv is set, the API key from it.
+func endpointDefaults(endpoint, keyEnv string) func(cfg *config.Config) {
+	return func(cfg *config.Config) {
+		cfg.LLM.Endpoint = endpoint
+		if k := os.Getenv(keyEnv); k != "" {
+			cfg.LLM.APIKey = k
+		}
+	}
+}
+
+// Providers returns the names of the registered providers, sorted.
+func Providers() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for _, r := range registry {
+		names = append(names, r.name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ProviderName returns the provider the configuration selects: the "provider"
+// setting, or else the first provider claiming the model.
+func ProviderName(cfg *config.Config) string {
+	if name := strings.ToLower(strings.TrimSpace(cfg.LLM.Provider)); name != "" {
+		return name
+	}
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	for _, r := range registry {
+		if r.matches != nil && r.matches(cfg) {
+			return r.name
+		}
+	}
+	return defaultProvider
+}
+
+// applyDefaults runs the defaults of the provider the configuration selects.
+func applyDefaults(cfg *config.Config) {
+	name := ProviderName(cfg)
+	registryMu.RLock()
+	var defaults func(cfg *config.Config)
+	for _, r := range registry {
+		if r.name == name {
+			defaults = r.defaults
+		}
+	}
+	registryMu.RUnlock()
+	if defaults != nil {
+		defaults(cfg)
+	}
+}
+
+// NewProvider builds the provider the configuration selects.
+func NewProvider(cfg *config.Config) (Provider, error) {
+	if cfg.LLM.Model == "" {
+		return nil, fmt.Errorf("model is required")
+	}
+	name := ProviderName(cfg)
+	registryMu.RLock()
+	var factory ProviderFactory
+	for _, r := range registry {
+		if r.name == name {
+			factory = r.factory
+		}
+	}
+	registryMu.RUnlock()
+	if factory == nil {
+		return nil, fmt.Errorf("unknown provider %q (registered: %s)", name, strings.Join(Providers(), ", "))
+	}
+	return factory(cfg)
+}
+
+// httpClients are shared by the built-in HTTP providers. The stream client has
+// no overall timeout, since a streamed response may take longer than
+// requestTimeout to complete; it fails when the response stalls instead.
+var httpClients = newHTTPClients()
+
+type clients struct {
+	plain  *http.Client
+	stream *http.Client
+}
+
+func newHTTPClients() clients {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = requestTimeout
+	return clients{
+		plain:  &http.Client{Timeout: requestTimeout},
+		stream: &http.Client{Transport: idleTransport{base: transport}},
+	}
+}
+
+// client returns the HTTP client for a streamed or a plain request.
+func (c clients) client(stream bool) *http.Client {
+	if stream {
+		return c.stream
+	}
+	return c.plain
+}
+
+// idleTimeoutError is returned when a streamed response sends nothing for
+// longer than the idle timeout. It is a timeout, so it is retried unless part
+// of the response was already shown.
+type idleTimeoutError struct {
+	timeout time.Duration
+}
+
+func (e *idleTimeoutError) Error() string {
+	return fmt.Sprintf("no data received from the server for %s", e.timeout)
+}
+
+func (e *idleTimeoutError) Timeout() bool   { return true }
+func (e *idleTimeoutError) Temporary() bool { return true }
+
+// idleTransport cancels a request once its response body has sent nothing for
+// streamIdleTimeout.
+type idleTransport struct {
+	base http.RoundTripper
+}
+
+func (t idleTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithCancel(req.Context())
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = newIdleBody(resp.Body, streamIdleTimeout, cancel)
+	return resp, nil
+}
+
+// idleBody is a response body whose request is cancelled when a read waits
+// longer than timeout for data.
+type idleBody struct {
+	io.ReadCloser
+	timeout time.Duration
+	timer   *time.Timer
+	expired atomic.Bool
+	cancel  context.CancelFunc
+}
+
+func newIdleBody(body io.ReadCloser, timeout time.Duration, cancel context.CancelFunc) *idleBody {
+	b := &idleBody{ReadCloser: body, timeout: timeout, cancel: cancel}
+	b.timer = time.AfterFunc(timeout, func() {
+		b.expired.Store(true)
+		cancel()
+	})
+	return b
+}
+
+func (b *idleBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil && b.expired.Load() {
+		return n, &idleTimeoutError{timeout: b.timeout}
+	}
+	if n > 0 {
+		b.timer.Reset(b.timeout)
+	}
+	return n, err
+}
+
+func (b *idleBody) Close() error {
+	b.timer.Stop()
+	b.cancel()
+	return b.ReadCloser.Close()
+}
@@ -0,0 +1,46 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestStreamStalledServer(t *testing.T) {
+	defer func(timeout time.Duration) { streamIdleTimeout = timeout }(streamIdleTimeout)
+	streamIdleTimeout = 100 * time.Millisecond
+
+	// The server sends the headers and one event, then stalls until the
+	// client gives up.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"partial\"}}]}\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	provider := &openAIProvider{endpoint: srv.URL, apiKey: "test-key"}
+	var pieces []string
+	done := make(chan error, 1)
+	go func() {
+		_, err := provider.Stream(ChatRequest{Model: "gpt-5"}, func(text string) { pieces = append(pieces, text) })
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		var idleErr *idleTimeoutError
+		if !errors.As(err, &idleErr) {
+			t.Errorf("Stream = %v, want an idle timeout", err)
+		}
+		if len(pieces) != 1 || pieces[0] != "partial" {
+			t.Errorf("streamed pieces = %q, want the one sent before the stall", pieces)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stream did not return after the server stalled")
+	}
+}
@@ -0,0 +1,162 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// Retry defaults. A delay the provider asks for beyond maxRetryAfter is not
+// waited for; the error is returned instead.
+const (
+	defaultMaxAttempts = 4
+	baseRetryDelay     = time.Second
+	maxRetryDelay      = 30 * time.Second
+	maxRetryAfter      = 2 * time.Minute
+)
+
+// StatusError is a request the provider answered with an error status.
+type StatusError struct {
+	// Request names the failed request in the message, such as
+	// "Anthropic API request".
+	Request    string
+	StatusCode int
+	Body       string
+	// RetryAfter is how long the provider asked to wait before retrying,
+	// or zero when it did not say.
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s failed with status %d: %s", e.Request, e.StatusCode, e.Body)
+}
+
+// HTTPStatusCode returns the status, as the AWS SDK errors do.
+func (e *StatusError) HTTPStatusCode() int {
+	return e.StatusCode
+}
+
+// newStatusError builds the error for a response with an error status and
+// its body.
+func newStatusError(request string, resp *http.Response, body []byte) *StatusError {
+	return &StatusError{
+		Request:    request,
+		StatusCode: resp.StatusCode,
+		Body:       string(body),
+		RetryAfter: retryAfter(resp.Header, time.Now()),
+	}
+}
+
+// anthropicLimits are the rate limits Anthropic reports in
+// anthropic-ratelimit-<limit>-remaining and -reset headers.
+var anthropicLimits = []string{"requests", "tokens", "input-tokens", "output-tokens"}
+
+// retryAfter reads the delay a response asks for: the Retry-After header, in
+// seconds or as a date, or else the time until Anthropic's exhausted rate
+// limits reset.
+func retryAfter(header http.Header, now time.Time) time.Duration {
+	if value := header.Get("Retry-After"); value != "" {
+		if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+			return time.Duration(seconds * float64(time.Second))
+		}
+		if at, err := http.ParseTime(value); err == nil && at.After(now) {
+			return at.Sub(now)
+		}
+	}
+
+	var wait time.Duration
+	for _, limit := range anthropicLimits {
+		if header.Get("anthropic-ratelimit-"+limit+"-remaining") != "0" {
+			continue
+		}
+		reset, err := time.Parse(time.RFC3339, header.Get("anthropic-ratelimit-"+limit+"-reset"))
+		if err == nil && reset.Sub(now) > wait {
+			wait = reset.Sub(now)
+		}
+	}
+	return wait
+}
+
+// anthropicStreamErrors maps the error types Anthropic sends in a stream to
+// the status the same error has outside of one, for those worth retrying.
+var anthropicStreamErrors = map[string]int{
+	"rate_limit_error": http.StatusTooManyRequests,
+	"api_error":        http.StatusInternalServerError,
+	"overloaded_error": 529,
+}
+
+// anthropicStreamError returns the error for an error event of a stream.
+func anthropicStreamError(errorType, message string) error {
+	if status, ok := anthropicStreamErrors[errorType]; ok {
+		return &StatusError{Request: "Anthropic API stream", StatusCode: status, Body: message}
+	}
+	return fmt.Errorf("Anthropic API error: %s", message)
+}
+
+// RetryCallback is told about each retry before waiting for it: the attempt
+// about to be made, the number allowed, the wait and the error that caused it.
+type RetryCallback func(attempt, maxAttempts int, delay time.Duration, err error)
+
+// retryPolicy decides whether and when a failed request is sent again.
+type retryPolicy struct {
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+}
+
+// retryPolicy returns the policy of the configured "max_attempts", where zero
+// means the default and one disables retries.
+func (c *Client) retryPolicy() retryPolicy {
+	attempts := c.config.LLM.MaxAttempts
+	if attempts <= 0 {
+		attempts = defaultMaxAttempts
+	}
+	return retryPolicy{maxAttempts: attempts, baseDelay: baseRetryDelay, maxDelay: maxRetryDelay}
+}
+
+// delay returns how long to wait before retrying after the given attempt
+// failed with err, and false when it should not be retried.
+func (p retryPolicy) delay(attempt int, err error) (time.Duration, bool) {
+	if attempt >= p.maxAttempts || !isRetryable(err) {
+		return 0, false
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.RetryAfter > 0 {
+		if statusErr.RetryAfter > maxRetryAfter {
+			return 0, false
+		}
+		return statusErr.RetryAfter, true
+	}
+
+	// Exponential backoff with jitter over the upper half, so clients that
+	// failed together do not retry together.
+	backoff := min(p.baseDelay<<(attempt-1), p.maxDelay)
+	return backoff/2 + rand.N(backoff/2+1), true
+}
+
+// isRetryable reports whether err is likely transient: a rate limit, an
+// overloaded or failing server, a timeout, or a connection the server reset or
+// closed partway. Other network errors, such as a refused connection or an
+// unknown host, are not retried.
+func isRetryable(err error) bool {
+	var status interface{ HTTPStatusCode() int }
+	if errors.As(err, &status) {
+		switch code := status.HTTPStatusCode(); code {
+		case http.StatusRequestTimeout, http.StatusTooManyRequests, 529:
+			return true
+		default:
+			return code >= 500 && code != http.StatusNotImplemented && code != http.StatusHTTPVersionNotSupported
+		}
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.ErrUnexpectedEOF)
+}
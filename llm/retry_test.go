@@ -0,0 +1,153 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		header map[string]string
+		want   time.Duration
+	}{
+		{"none", nil, 0},
+		{"seconds", map[string]string{"Retry-After": "7"}, 7 * time.Second},
+		{"fractional seconds", map[string]string{"Retry-After": "1.5"}, 1500 * time.Millisecond},
+		{"zero seconds", map[string]string{"Retry-After": "0"}, 0},
+		{"date", map[string]string{"Retry-After": now.Add(45 * time.Second).Format(http.TimeFormat)}, 45 * time.Second},
+		{"past date", map[string]string{"Retry-After": now.Add(-time.Minute).Format(http.TimeFormat)}, 0},
+		{"invalid", map[string]string{"Retry-After": "soon"}, 0},
+		{"anthropic reset", map[string]string{
+			"anthropic-ratelimit-requests-remaining": "0",
+			"anthropic-ratelimit-requests-reset":     now.Add(20 * time.Second).Format(time.RFC3339),
+		}, 20 * time.Second},
+		{"anthropic limit not exhausted", map[string]string{
+			"anthropic-ratelimit-tokens-remaining": "1200",
+			"anthropic-ratelimit-tokens-reset":     now.Add(20 * time.Second).Format(time.RFC3339),
+		}, 0},
+		{"latest exhausted anthropic reset", map[string]string{
+			"anthropic-ratelimit-requests-remaining":      "0",
+			"anthropic-ratelimit-requests-reset":          now.Add(5 * time.Second).Format(time.RFC3339),
+			"anthropic-ratelimit-input-tokens-remaining":  "0",
+			"anthropic-ratelimit-input-tokens-reset":      now.Add(30 * time.Second).Format(time.RFC3339),
+			"anthropic-ratelimit-output-tokens-remaining": "10",
+			"anthropic-ratelimit-output-tokens-reset":     now.Add(time.Minute).Format(time.RFC3339),
+		}, 30 * time.Second},
+		{"Retry-After before anthropic reset", map[string]string{
+			"Retry-After":                          "3",
+			"anthropic-ratelimit-tokens-remaining": "0",
+			"anthropic-ratelimit-tokens-reset":     now.Add(time.Minute).Format(time.RFC3339),
+		}, 3 * time.Second},
+	}
+	for _, tt := range tests {
+		header := make(http.Header)
+		for key, value := range tt.header {
+			header.Set(key, value)
+		}
+		if got := retryAfter(header, now); got != tt.want {
+			t.Errorf("%s: retryAfter = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// timeoutError is a network error that timed out.
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+// requestError wraps err the way http.Client returns a failed request.
+func requestError(err error) error {
+	return &url.Error{Op: "Post", URL: "https://api.example.com", Err: err}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"rate limited", &StatusError{StatusCode: http.StatusTooManyRequests}, true},
+		{"overloaded", &StatusError{StatusCode: 529}, true},
+		{"request timeout", &StatusError{StatusCode: http.StatusRequestTimeout}, true},
+		{"server error", &StatusError{StatusCode: http.StatusBadGateway}, true},
+		{"not implemented", &StatusError{StatusCode: http.StatusNotImplemented}, false},
+		{"bad request", &StatusError{StatusCode: http.StatusBadRequest}, false},
+		{"unauthorized", &StatusError{StatusCode: http.StatusUnauthorized}, false},
+		{"wrapped status", fmt.Errorf("review: %w", &StatusError{StatusCode: http.StatusServiceUnavailable}), true},
+		{"timeout", requestError(timeoutError{}), true},
+		{"connection reset", requestError(&net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}), true},
+		{"unexpected EOF", fmt.Errorf("failed to read response: %w", io.ErrUnexpectedEOF), true},
+		{"connection refused", requestError(&net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}), false},
+		{"unknown host", requestError(&net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "no such host", Name: "api.example.com", IsNotFound: true}}), false},
+		{"reset mentioned in text only", errors.New("upstream said: connection reset by peer"), false},
+		{"other error", errors.New("failed to marshal request"), false},
+	}
+	for _, tt := range tests {
+		if got := isRetryable(tt.err); got != tt.want {
+			t.Errorf("%s: isRetryable(%v) = %t, want %t", tt.name, tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	policy := retryPolicy{maxAttempts: 4, baseDelay: time.Second, maxDelay: 30 * time.Second}
+	rateLimited := func(after time.Duration) error {
+		return &StatusError{StatusCode: http.StatusTooManyRequests, RetryAfter: after}
+	}
+	tests := []struct {
+		name    string
+		attempt int
+		err     error
+		want    time.Duration
+		retry   bool
+	}{
+		{"asked delay", 1, rateLimited(12 * time.Second), 12 * time.Second, true},
+		{"asked delay at the cap", 1, rateLimited(maxRetryAfter), maxRetryAfter, true},
+		{"asked delay beyond the cap", 1, rateLimited(maxRetryAfter + time.Second), 0, false},
+		{"last attempt", 4, rateLimited(time.Second), 0, false},
+		{"not retryable", 1, &StatusError{StatusCode: http.StatusBadRequest, RetryAfter: time.Second}, 0, false},
+	}
+	for _, tt := range tests {
+		got, retry := policy.delay(tt.attempt, tt.err)
+		if got != tt.want || retry != tt.retry {
+			t.Errorf("%s: delay = %v, %t, want %v, %t", tt.name, got, retry, tt.want, tt.retry)
+		}
+	}
+}
+
+func TestRetryBackoffJitter(t *testing.T) {
+	policy := retryPolicy{maxAttempts: 10, baseDelay: time.Second, maxDelay: 30 * time.Second}
+	err := &StatusError{StatusCode: http.StatusServiceUnavailable}
+	tests := []struct {
+		attempt int
+		backoff time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{5, 16 * time.Second},
+		// Capped at maxDelay.
+		{6, 30 * time.Second},
+		{9, 30 * time.Second},
+	}
+	for _, tt := range tests {
+		for range 200 {
+			got, retry := policy.delay(tt.attempt, err)
+			if !retry || got < tt.backoff/2 || got > tt.backoff {
+				t.Fatalf("attempt %d: delay = %v, %t, want a retry within [%v, %v]", tt.attempt, got, retry, tt.backoff/2, tt.backoff)
+			}
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package llm
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxEventSize bounds a single server-sent event; patches arrive in small
+// deltas, so this only guards against a misbehaving server.
+const maxEventSize = 1024 * 1024
+
+// readSSE reads a text/event-stream body and calls handle with the event name
+// and data of every event, stopping at the first error handle returns.
+func readSSE(body io.Reader, handle func(event, data string) error) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), maxEventSize)
+
+	var event string
+	var data []string
+	dispatch := func() error {
+		if len(data) == 0 {
+			event = ""
+			return nil
+		}
+		err := handle(event, strings.Join(data, "\n"))
+		event, data = "", nil
+		return err
+	}
+	for scanner.Scan() {
+		line := scanner.Text()
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch {
+		case line == "":
+			if err := dispatch(); err != nil {
+				return err
+			}
+		case field == "event":
+			event = value
+		case field == "data":
+			data = append(data, value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return dispatch()
+}
+
+// isEventStream reports whether resp is a stream of server-sent events.
+func isEventStream(resp *http.Response) bool {
+	return strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream")
+}
+
+// anthropicStreamEvent is an event of the Anthropic Messages streaming API,
+// which Bedrock also uses for Anthropic models. Only text deltas, usage and
+// errors matter here.
+type anthropicStreamEvent struct {
+	Type  string `json:"type"`
+	Delta struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"delta"`
+	// Message carries the input usage on message_start, and Usage the output
+	// usage so far on message_delta.
+	Message struct {
+		Usage anthropicUsage `json:"usage"`
+	} `json:"message"`
+	Usage anthropicUsage `json:"usage"`
+	Error *struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// text returns the text an event adds to the response.
+func (e anthropicStreamEvent) text() string {
+	if e.Type == "content_block_delta" && e.Delta.Type == "text_delta" {
+		return e.Delta.Text
+	}
+	return ""
+}
+
+type anthropicUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+// addUsage updates the usage of a streamed response with an event.
+func (e anthropicStreamEvent) addUsage(usage Usage) Usage {
+	switch e.Type {
+	case "message_start":
+		usage.InputTokens = e.Message.Usage.InputTokens
+		usage.OutputTokens = e.Message.Usage.OutputTokens
+	case "message_delta":
+		usage.OutputTokens = e.Usage.OutputTokens
+	}
+	return usage
+}
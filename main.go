@@ -9,6 +9,7 @@ import (
 	"github.com/piqoni/vogte/app"
 	"github.com/piqoni/vogte/cli"
 	"github.com/piqoni/vogte/config"
+	"github.com/piqoni/vogte/parser"
 )
 
 func main() {
@@ -22,7 +23,20 @@ func main() {
 	configPtr := flag.String("config", "", "Path to config file. Example: vogte -config config.json ")
 	dirPtr := flag.String("dir", pwd, "The directory to analyze")
 	contextPtr := flag.Bool("generate-context", false, "Generate context file (vogte-context.txt)")
+	formatPtr := flag.String("format", "text", "Format of the generated context: text, json, markdown or xml. json always contains every symbol and is not compacted to -max-tokens")
+	outputPtr := flag.String("o", "", "Output path for -generate-context, or - for stdout (default vogte-context.txt, or .json/.md/.xml to match -format)")
 	modelPtr := flag.String("model", "", "LLM model name (overrides config)")
+	maxTokensPtr := flag.Int("max-tokens", 0, "Token budget for the context; larger projects are ranked and compacted to fit (not applied to -format json)")
+	includePtr := flag.String("include", "", "Comma-separated globs of files to include in the context. Example: -include 'internal/**,cmd/**'")
+	excludePtr := flag.String("exclude", "", "Comma-separated globs of files to leave out of the context. Example: -exclude '**/*_mock.go'")
+	forcePtr := flag.Bool("force", false, "Allow AGENT mode to edit generated files (\"Code generated ... DO NOT EDIT\")")
+	pkgPtr := flag.String("pkg", "", "Comma-separated package patterns to restrict the context to, with signatures of their internal dependencies. Example: -pkg ./internal/billing/...")
+	noTestsPtr := flag.Bool("no-tests", false, "Leave _test.go files out of the context")
+	apiOnlyPtr := flag.Bool("api-only", false, "Only include the exported API: exported functions, types, fields and methods")
+	callersPtr := flag.Bool("callers", false, "Add the callers of each exported function to the context")
+	depsPtr := flag.Bool("deps", false, "Add the third-party APIs the project references, read offline from vendor/ or the module cache")
+	tagsPtr := flag.String("tags", "", "Comma-separated build tags to satisfy when selecting files. Set GOOS/GOARCH in the environment to target another platform")
+	typesPtr := flag.Bool("types", false, "Type-check the project and add method sets and interface implementations to the context")
 	flag.Parse()
 
 	cfg := config.Load(*configPtr)
@@ -33,6 +47,31 @@ func main() {
 	if *modelPtr != "" {
 		cfg.SetModel(*modelPtr)
 	}
+	if *typesPtr {
+		cfg.Context.TypeCheck = true
+	}
+	if *noTestsPtr {
+		cfg.Context.SkipTests = true
+	}
+	if *apiOnlyPtr {
+		cfg.Context.APIOnly = true
+	}
+	if *callersPtr {
+		cfg.Context.Callers = true
+	}
+	if *depsPtr {
+		cfg.Context.Dependencies = true
+	}
+	if *forcePtr {
+		cfg.Patch.AllowGenerated = true
+	}
+	if *maxTokensPtr > 0 {
+		cfg.Context.MaxTokens = *maxTokensPtr
+	}
+	cfg.Context.Include = append(cfg.Context.Include, splitList(*includePtr)...)
+	cfg.Context.Exclude = append(cfg.Context.Exclude, splitList(*excludePtr)...)
+	cfg.Context.Tags = append(cfg.Context.Tags, splitList(*tagsPtr)...)
+	cfg.Context.Packages = append(cfg.Context.Packages, splitList(*pkgPtr)...)
 
 	initialMode := "ASK"
 	if *agentPtr {
@@ -53,7 +92,18 @@ func main() {
 
 	// CLI mode
 	if *contextPtr {
-		if err := cli.Run(application, contextFile); err != nil {
+		format, err := parser.ParseFormat(*formatPtr)
+		if err != nil {
+			log.Fatalf("CLI error: %v", err)
+		}
+		if format == parser.FormatJSON && *maxTokensPtr > 0 {
+			log.Fatalf("CLI error: -max-tokens does not apply to -format json, which always contains every symbol")
+		}
+		outputPath := *outputPtr
+		if outputPath == "" {
+			outputPath = contextFileFor(format)
+		}
+		if err := cli.Run(application, outputPath, format); err != nil {
 			log.Fatalf("CLI error: %v", err)
 		}
 		return
@@ -64,3 +114,27 @@ func main() {
 		log.Printf("Application error: %v", err)
 	}
 }
+
+// splitList splits a comma-separated flag value, dropping empty entries.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// contextFileFor names the default context file after the output format.
+func contextFileFor(format parser.Format) string {
+	switch format {
+	case parser.FormatJSON:
+		return "vogte-context.json"
+	case parser.FormatMarkdown:
+		return "vogte-context.md"
+	case parser.FormatXML:
+		return "vogte-context.xml"
+	}
+	return "vogte-context.txt"
+}
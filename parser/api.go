@@ -0,0 +1,264 @@
+package parser
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"strings"
+)
+
+// apiOnly reports whether the blueprint is limited to the exported API.
+func (p *Parser) apiOnly() bool {
+	return p.config.Context.APIOnly
+}
+
+// exported reports whether a symbol belongs to the exported API: its name and,
+// for methods, its receiver type are exported.
+func (sym Symbol) exported() bool {
+	return token.IsExported(sym.Name) && (sym.Receiver == "" || token.IsExported(sym.Receiver))
+}
+
+// exportedNames counts the exported names a symbol declares; const groups
+// declare several.
+func (sym Symbol) exportedNames() int {
+	if sym.Kind == "generated" || sym.Receiver != "" && !token.IsExported(sym.Receiver) {
+		return 0
+	}
+	count := 0
+	for _, name := range strings.Split(sym.Name, ", ") {
+		if token.IsExported(name) {
+			count++
+		}
+	}
+	return count
+}
+
+// exportedSpec returns a copy of a struct or interface type spec without its
+// unexported fields and methods. Struct tags are kept since they define the
+// wire format of the exported fields.
+func exportedSpec(spec *ast.TypeSpec) *ast.TypeSpec {
+	copied := *spec
+	switch t := spec.Type.(type) {
+	case *ast.StructType:
+		st := *t
+		st.Fields = exportedFields(t.Fields)
+		copied.Type = &st
+	case *ast.InterfaceType:
+		it := *t
+		it.Methods = exportedFields(t.Methods)
+		copied.Type = &it
+	}
+	return &copied
+}
+
+func exportedFields(fields *ast.FieldList) *ast.FieldList {
+	if fields == nil {
+		return nil
+	}
+	filtered := *fields
+	filtered.List = nil
+	for _, field := range fields.List {
+		if len(field.Names) == 0 {
+			// Embedded fields are named after their type.
+			if name := embeddedName(field.Type); token.IsExported(name) {
+				filtered.List = append(filtered.List, field)
+			}
+			continue
+		}
+		var names []*ast.Ident
+		for _, name := range field.Names {
+			if name.IsExported() {
+				names = append(names, name)
+			}
+		}
+		if len(names) == len(field.Names) {
+			filtered.List = append(filtered.List, field)
+		} else if len(names) > 0 {
+			f := *field
+			f.Names = names
+			filtered.List = append(filtered.List, &f)
+		}
+	}
+	return &filtered
+}
+
+func embeddedName(expr ast.Expr) string {
+	if sel, ok := expr.(*ast.SelectorExpr); ok {
+		return sel.Sel.Name
+	}
+	return receiverName(expr)
+}
+
+// constructedType returns the package-level type a function constructs, as
+// go/doc decides it: the only named local type among its results, possibly
+// behind a pointer, next to an optional error.
+func constructedType(fn *ast.FuncDecl) string {
+	if fn.Recv != nil || fn.Type.Results == nil {
+		return ""
+	}
+	name := ""
+	for _, result := range fn.Type.Results.List {
+		expr := result.Type
+		if star, ok := expr.(*ast.StarExpr); ok {
+			expr = star.X
+		}
+		ident, ok := expr.(*ast.Ident)
+		if !ok || types.Universe.Lookup(ident.Name) != nil {
+			continue
+		}
+		if name != "" && name != ident.Name {
+			return ""
+		}
+		name = ident.Name
+	}
+	return name
+}
+
+// groupByType orders symbols the way go/doc presents a package: each type is
+// followed by its constructors and methods, and the remaining functions and
+// declarations keep their place.
+func groupByType(symbols []Symbol) []Symbol {
+	typeNames := make(map[string]bool)
+	for _, sym := range symbols {
+		if sym.Kind == "type" {
+			typeNames[sym.Name] = true
+		}
+	}
+	owner := func(sym Symbol) string {
+		switch {
+		case sym.Kind == "method" && typeNames[sym.Receiver]:
+			return sym.Receiver
+		case sym.Kind == "func" && typeNames[sym.Constructs]:
+			return sym.Constructs
+		}
+		return ""
+	}
+
+	grouped := make([]Symbol, 0, len(symbols))
+	for _, sym := range symbols {
+		if owner(sym) != "" {
+			continue
+		}
+		grouped = append(grouped, sym)
+		if sym.Kind != "type" {
+			continue
+		}
+		// Constructors come before methods, as in go doc.
+		for _, kind := range []string{"func", "method"} {
+			for _, member := range symbols {
+				if member.Kind == kind && owner(member) == sym.Name {
+					grouped = append(grouped, member)
+				}
+			}
+		}
+	}
+	return grouped
+}
+
+// groupPackages moves the constructors and methods declared in another file
+// of the package than their type next to the type, since go/doc groups them
+// per package rather than per file. Moved symbols lose their line number,
+// which belongs to the file they came from.
+func groupPackages(files []*File) {
+	byPackage := make(map[string][]*File)
+	var keys []string
+	for _, file := range files {
+		key := packageKey(file)
+		if len(byPackage[key]) == 0 {
+			keys = append(keys, key)
+		}
+		byPackage[key] = append(byPackage[key], file)
+	}
+
+	for _, key := range keys {
+		pkgFiles := byPackage[key]
+		if len(pkgFiles) < 2 {
+			continue
+		}
+		typeFiles := make(map[string]*File)
+		for _, file := range pkgFiles {
+			for _, sym := range file.Symbols {
+				if sym.Kind == "type" {
+					typeFiles[sym.Name] = file
+				}
+			}
+		}
+
+		moved := make(map[*File][]Symbol)
+		for _, file := range pkgFiles {
+			var kept []Symbol
+			for _, sym := range file.Symbols {
+				owner := ""
+				switch sym.Kind {
+				case "method":
+					owner = sym.Receiver
+				case "func":
+					owner = sym.Constructs
+				}
+				if target := typeFiles[owner]; target != nil && target != file {
+					sym.Line = 0
+					moved[target] = append(moved[target], sym)
+					continue
+				}
+				kept = append(kept, sym)
+			}
+			if len(kept) < len(file.Symbols) {
+				file.Symbols = kept
+			}
+		}
+		for _, file := range pkgFiles {
+			if members := moved[file]; len(members) > 0 {
+				file.Symbols = groupByType(append(file.Symbols[:len(file.Symbols):len(file.Symbols)], members...))
+			}
+		}
+	}
+}
+
+// renderAPI writes the number of exported symbols in each package when the
+// blueprint is limited to the exported API.
+func (proj *Project) renderAPI() string {
+	if !proj.apiOnly {
+		return ""
+	}
+	counts := proj.exportedCounts()
+	if len(counts) == 0 {
+		return ""
+	}
+	var builder strings.Builder
+	builder.WriteString("api (exported symbols per package):\n")
+	seen := make(map[string]bool)
+	for _, file := range proj.Files {
+		key := packageKey(file)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		name := file.Package
+		if name == "" {
+			name = "proto"
+		}
+		builder.WriteString(fmt.Sprintf("package %s (%s): %s\n", name, filepath.Dir(file.Path), pluralize(counts[key], "exported symbol")))
+	}
+	builder.WriteString("\n")
+	return builder.String()
+}
+
+// exportedCounts counts the exported names of each package, keyed by
+// packageKey.
+func (proj *Project) exportedCounts() map[string]int {
+	counts := make(map[string]int)
+	for _, file := range proj.Files {
+		for _, sym := range file.Symbols {
+			counts[packageKey(file)] += sym.exportedNames()
+		}
+	}
+	return counts
+}
+
+// packageKey identifies the package of a file by its directory and name, since
+// Go and proto packages can share a directory.
+func packageKey(file *File) string {
+	return filepath.Dir(file.Path) + "\x00" + file.Package
+}
@@ -0,0 +1,214 @@
+package parser
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// summaryNamesLimit caps the characters spent listing omitted symbol names on a
+// package summary line.
+const summaryNamesLimit = 80
+
+// EstimateTokens approximates the token count of s using the common heuristic
+// of four characters per token.
+func EstimateTokens(s string) int {
+	return (len(s) + 3) / 4
+}
+
+// rankedSymbol is a symbol scored against the task, remembering its position so
+// kept symbols can be rendered back in source order.
+type rankedSymbol struct {
+	file  *File
+	index int
+	score float64
+}
+
+// packageSummary collects the symbols of one package that did not fit within
+// the budget.
+type packageSummary struct {
+	name    string
+	dir     string
+	total   int
+	score   float64
+	omitted []string
+}
+
+// Compact renders the project within budget tokens. When the full blueprint
+// does not fit, files and symbols are ranked against the task, the top-ranked
+// signatures are kept and the rest are folded into one line per package. A
+// budget of zero or less renders the full blueprint.
+func (p *Parser) Compact(project *Project, task string, budget int) string {
+	return p.compact(project, task, budget, textRenderer{})
+}
+
+func (p *Parser) compact(project *Project, task string, budget int, r renderer) string {
+	full := project.render(r)
+	if budget <= 0 || EstimateTokens(full) <= budget {
+		return full
+	}
+
+	scores := fileScores(project, task)
+	terms, words := taskTerms(task), taskWords(task)
+
+	var ranked []rankedSymbol
+	packages := make(map[string]*packageSummary)
+	var order []*packageSummary
+	for _, file := range project.Files {
+		key := packageKey(file)
+		pkg, ok := packages[key]
+		if !ok {
+			pkg = &packageSummary{name: file.Package, dir: filepath.Dir(file.Path)}
+			packages[key] = pkg
+			order = append(order, pkg)
+		}
+		pkg.total += len(file.Symbols)
+		pkg.score = max(pkg.score, scores[file])
+		for i, sym := range file.Symbols {
+			ranked = append(ranked, rankedSymbol{
+				file:  file,
+				index: i,
+				score: scores[file] + symbolScore(sym, terms, words),
+			})
+		}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].score > ranked[j].score })
+	sort.SliceStable(order, func(i, j int) bool { return order[i].score > order[j].score })
+
+	// Reserve room for the package summary lines first, using their worst-case
+	// length, so the kept signatures can never push the output over budget.
+	// Summaries are capped at a quarter of the budget; packages beyond that are
+	// only counted on a trailing line.
+	// Diagnostics are kept so the model knows which files are missing, but list
+	// fewer entries when they would take more than a quarter of the budget.
+	var diagnostics string
+	for limit := maxRenderedDiagnostics; limit >= 0; limit-- {
+		diagnostics = r.section("diagnostics", renderDiagnostics(project.Diagnostics, limit))
+		if EstimateTokens(diagnostics) <= budget/4 {
+			break
+		}
+	}
+	remaining := budget - EstimateTokens(diagnostics+r.begin()+r.end())
+	// The import graph is small and tells the model what a change affects, so
+	// it is kept as long as it takes no more than an eighth of the budget.
+	imports := r.section("imports", project.renderImports())
+	if EstimateTokens(imports) > budget/8 {
+		imports = ""
+	}
+	remaining -= EstimateTokens(imports)
+	summarized := order
+	trailer := fmt.Sprintf("(%d more packages omitted)\n", len(order))
+	remaining -= EstimateTokens(r.section("omitted", trailer))
+	if project.Workspace != nil {
+		remaining -= EstimateTokens(r.workspace(project.Workspace))
+	}
+	if len(project.Scope) > 0 {
+		remaining -= EstimateTokens(r.scope(project.Scope))
+	}
+	for _, m := range project.Modules {
+		remaining -= EstimateTokens(r.module(m, ""))
+	}
+	summaries := 0
+	for i, pkg := range order {
+		cost := EstimateTokens(pkg.summaryLine(pkg.total, strings.Repeat("x", summaryNamesLimit+len(", ..."))))
+		if summaries+cost > budget/4 || cost > remaining {
+			summarized = order[:i]
+			break
+		}
+		summaries += cost
+		remaining -= cost
+	}
+
+	kept := make(map[*File][]bool)
+	for _, rs := range ranked {
+		sym := rs.file.Symbols[rs.index]
+		cost := EstimateTokens(symbolText(sym) + "\n")
+		if kept[rs.file] == nil {
+			cost += EstimateTokens(r.file(rs.file, nil))
+		}
+		if cost > remaining {
+			continue
+		}
+		remaining -= cost
+		if kept[rs.file] == nil {
+			kept[rs.file] = make([]bool, len(rs.file.Symbols))
+		}
+		kept[rs.file][rs.index] = true
+	}
+
+	var result strings.Builder
+	result.WriteString(r.begin())
+	result.WriteString(project.renderFiles(r, func(file *File) string {
+		flags := kept[file]
+		if flags == nil {
+			return ""
+		}
+		var symbols []Symbol
+		for i, sym := range file.Symbols {
+			if flags[i] {
+				symbols = append(symbols, sym)
+			}
+		}
+		return r.file(file, symbols)
+	}))
+
+	// Omitted names are listed best-ranked first.
+	for _, rs := range ranked {
+		if flags := kept[rs.file]; flags == nil || !flags[rs.index] {
+			pkg := packages[packageKey(rs.file)]
+			pkg.omitted = append(pkg.omitted, rs.file.Symbols[rs.index].Name)
+		}
+	}
+	var omitted strings.Builder
+	for _, pkg := range summarized {
+		if len(pkg.omitted) > 0 {
+			omitted.WriteString(pkg.summaryLine(len(pkg.omitted), pkg.names()))
+		}
+	}
+	if hidden := len(order) - len(summarized); hidden > 0 {
+		omitted.WriteString(fmt.Sprintf("(%d more packages omitted)\n", hidden))
+	}
+	result.WriteString(r.section("omitted", omitted.String()))
+	result.WriteString(imports)
+
+	if callers := r.section("callers", project.renderCallers()); EstimateTokens(callers) <= remaining {
+		result.WriteString(callers)
+		remaining -= EstimateTokens(callers)
+	}
+	if types := r.section("types", project.Types); EstimateTokens(types) <= remaining {
+		result.WriteString(types)
+		remaining -= EstimateTokens(types)
+	}
+	if dependencies := r.section("dependencies", project.Dependencies); EstimateTokens(dependencies) <= remaining {
+		result.WriteString(dependencies)
+	}
+	result.WriteString(diagnostics)
+	result.WriteString(r.end())
+
+	return result.String()
+}
+
+func (pkg *packageSummary) summaryLine(omitted int, names string) string {
+	name := pkg.name
+	if name == "" {
+		name = "proto"
+	}
+	return fmt.Sprintf("package %s (%s): %d of %d symbols omitted: %s\n", name, pkg.dir, omitted, pkg.total, names)
+}
+
+// names lists the omitted symbol names, truncated to summaryNamesLimit.
+func (pkg *packageSummary) names() string {
+	var names string
+	for i, name := range pkg.omitted {
+		next := name
+		if i > 0 {
+			next = ", " + name
+		}
+		if len(names)+len(next) > summaryNamesLimit {
+			return names + ", ..."
+		}
+		names += next
+	}
+	return names
+}
@@ -0,0 +1,37 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/piqoni/vogte/config"
+)
+
+func TestCompactStaysWithinBudget(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, 20, 3)
+	broken := make(map[string]string)
+	for i := range 40 {
+		broken[fmt.Sprintf("broken/file%03d.go", i)] = "package broken\n\nfunc {\n"
+	}
+	writeFiles(t, dir, broken)
+
+	p := New(&config.Config{})
+	project, err := p.Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, budget := range []int{500, 2000} {
+		output := p.Compact(project, "increment the counter", budget)
+		if tokens := EstimateTokens(output); tokens > budget {
+			t.Errorf("Compact(%d) used %d tokens:\n%s", budget, tokens, output)
+		}
+		if !strings.Contains(output, "symbols omitted") {
+			t.Errorf("Compact(%d) has no package summary lines:\n%s", budget, output)
+		}
+		if !strings.Contains(output, "broken/file000.go") {
+			t.Errorf("Compact(%d) dropped the diagnostics:\n%s", budget, output)
+		}
+	}
+}
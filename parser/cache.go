@@ -0,0 +1,148 @@
+package parser
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// cacheVersion must be bumped whenever the File summary or the way it is built
+// changes, so entries written by older versions are discarded instead of
+// served.
+const cacheVersion = 15
+
+// cacheEntry is the cached summary of one file. An entry is reused when the
+// file's modification time and size are unchanged, or failing that, when its
+// content hash still matches.
+type cacheEntry struct {
+	ModTime int64  `json:"mod_time"`
+	Size    int64  `json:"size"`
+	Hash    string `json:"hash"`
+	Module  string `json:"module"` // module paths the imports were filtered against
+	File    *File  `json:"file"`
+}
+
+// parseCache holds per-file summaries under .vogte/cache in the project
+// directory so only changed files are parsed again. It is safe for concurrent
+// use by the parse workers.
+type parseCache struct {
+	Version int                    `json:"version"`
+	Options string                 `json:"options"` // settings the summaries were built with
+	Entries map[string]*cacheEntry `json:"entries"`
+
+	mu    sync.Mutex
+	path  string
+	seen  map[string]bool
+	dirty bool
+}
+
+func (p *Parser) loadCache(dir string) *parseCache {
+	c := &parseCache{
+		Version: cacheVersion,
+		Options: p.cacheOptions(),
+		Entries: make(map[string]*cacheEntry),
+		path:    filepath.Join(dir, ".vogte", "cache", "files.json"),
+		seen:    make(map[string]bool),
+	}
+
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		return c
+	}
+	var stored parseCache
+	if err := json.Unmarshal(data, &stored); err != nil || stored.Version != cacheVersion || stored.Options != c.Options {
+		c.dirty = true
+		return c
+	}
+	if stored.Entries != nil {
+		c.Entries = stored.Entries
+	}
+	return c
+}
+
+// cacheOptions describes the settings that change how files are summarized, so
+// a cache built with other settings is discarded.
+func (p *Parser) cacheOptions() string {
+	return fmt.Sprintf("docs=%s api-only=%t terms=%t", p.docsMode(), p.apiOnly(), p.indexesTerms())
+}
+
+// get returns the cached summary of the file at path, calling parse and
+// storing the result when the file changed since it was cached.
+func (c *parseCache) get(rel, path string, info os.FileInfo, module string, parse func() (*File, error)) (*File, error) {
+	c.mu.Lock()
+	c.seen[rel] = true
+	entry, ok := c.Entries[rel]
+	usable := ok && entry.Module == module && entry.File != nil
+	if usable && entry.ModTime == info.ModTime().UnixNano() && entry.Size == info.Size() {
+		c.mu.Unlock()
+		return entry.File, nil
+	}
+	c.mu.Unlock()
+
+	hash := fileHash(path)
+	if usable && hash != "" && entry.Hash == hash {
+		c.mu.Lock()
+		entry.ModTime = info.ModTime().UnixNano()
+		entry.Size = info.Size()
+		c.dirty = true
+		c.mu.Unlock()
+		return entry.File, nil
+	}
+
+	file, err := parse()
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Entries[rel] = &cacheEntry{
+		ModTime: info.ModTime().UnixNano(),
+		Size:    info.Size(),
+		Hash:    hash,
+		Module:  module,
+		File:    file,
+	}
+	c.dirty = true
+	return file, nil
+}
+
+// save drops entries for files that were not seen during the walk and writes
+// the cache back if anything changed. Failures are ignored since the cache is
+// only an optimization.
+func (c *parseCache) save() {
+	for rel := range c.Entries {
+		if !c.seen[rel] {
+			delete(c.Entries, rel)
+			c.dirty = true
+		}
+	}
+	if !c.dirty {
+		return
+	}
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return
+	}
+	_ = os.Rename(tmp, c.path)
+}
+
+func fileHash(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
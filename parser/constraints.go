@@ -0,0 +1,188 @@
+package parser
+
+import (
+	"bufio"
+	"go/build"
+	"go/build/constraint"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// knownOS and knownArch are the GOOS and GOARCH values recognized in file name
+// suffixes such as _windows.go or _linux_arm64.go, as listed by go/build.
+var knownOS = map[string]bool{
+	"aix": true, "android": true, "darwin": true, "dragonfly": true, "freebsd": true,
+	"hurd": true, "illumos": true, "ios": true, "js": true, "linux": true, "nacl": true,
+	"netbsd": true, "openbsd": true, "plan9": true, "solaris": true, "wasip1": true,
+	"windows": true, "zos": true,
+}
+
+var knownArch = map[string]bool{
+	"386": true, "amd64": true, "amd64p32": true, "arm": true, "armbe": true, "arm64": true,
+	"arm64be": true, "loong64": true, "mips": true, "mipsle": true, "mips64": true,
+	"mips64le": true, "mips64p32": true, "mips64p32le": true, "ppc": true, "ppc64": true,
+	"ppc64le": true, "riscv": true, "riscv64": true, "s390": true, "s390x": true,
+	"sparc": true, "sparc64": true, "wasm": true,
+}
+
+// buildContext returns the context Go files are matched against: the host's
+// GOOS, GOARCH and build tags unless the config overrides them.
+func (p *Parser) buildContext() *build.Context {
+	ctxt := build.Default
+	if goos := p.config.Context.GOOS; goos != "" {
+		ctxt.GOOS = goos
+	}
+	if goarch := p.config.Context.GOARCH; goarch != "" {
+		ctxt.GOARCH = goarch
+	}
+	ctxt.BuildTags = append(ctxt.BuildTags, p.config.Context.Tags...)
+	return &ctxt
+}
+
+// excludedFile is a Go file left out by its build constraints. It is kept only
+// to note the variant on a file that is summarized.
+func (p *Parser) excludedFile(filePath string) *File {
+	file := &File{excluded: variantLabel(filePath)}
+	if node, err := parser.ParseFile(token.NewFileSet(), filePath, nil, parser.PackageClauseOnly); err == nil {
+		file.Package = node.Name.Name
+	}
+	return file
+}
+
+// variantLabel describes what a file was built for: the GOOS and GOARCH of its
+// name suffix, or else its //go:build expression.
+func variantLabel(filePath string) string {
+	_, suffix := fileStem(filepath.Base(filePath))
+	if suffix != "" {
+		return suffix
+	}
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "other"
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if constraint.IsGoBuild(line) {
+			if expr, err := constraint.Parse(line); err == nil {
+				return expr.String()
+			}
+		}
+		if strings.HasPrefix(line, "package ") {
+			break
+		}
+	}
+	return "other"
+}
+
+// fileStem splits a Go file name into its stem and its GOOS/GOARCH suffix, so
+// that config_windows.go and config_linux_arm64.go both have the stem
+// "config".
+func fileStem(name string) (string, string) {
+	stem := strings.TrimSuffix(name, ".go")
+	test := strings.HasSuffix(stem, "_test")
+	stem = strings.TrimSuffix(stem, "_test")
+
+	var suffix []string
+	parts := strings.Split(stem, "_")
+	for len(parts) > 1 && len(suffix) < 2 {
+		last := parts[len(parts)-1]
+		if !knownOS[last] && !knownArch[last] {
+			break
+		}
+		suffix = append([]string{last}, suffix...)
+		parts = parts[:len(parts)-1]
+	}
+	stem = strings.Join(parts, "_")
+	if test {
+		stem += "_test"
+	}
+	return stem, strings.Join(suffix, "/")
+}
+
+// noteVariants collapses the excluded files into notes on the summarized file
+// of the same package with the same stem, or failing that, the first file of
+// the package. When a package has no summarized file, its first excluded file
+// stays as a stand-in so the package is not lost.
+func (p *Parser) noteVariants(files []*File) []*File {
+	var kept []*File
+	for _, file := range files {
+		if file.excluded == "" {
+			kept = append(kept, file)
+		}
+	}
+
+	variants := make(map[*File][]*File)
+	var targets []*File
+	for _, file := range files {
+		if file.excluded == "" {
+			continue
+		}
+		target := variantTarget(kept, file)
+		if target == nil {
+			ctxt := p.buildContext()
+			file.Notes = []string{file.excluded + " variant, excluded for " + ctxt.GOOS + "/" + ctxt.GOARCH + " by build constraints"}
+			kept = append(kept, file)
+			continue
+		}
+		if len(variants[target]) == 0 {
+			targets = append(targets, target)
+		}
+		variants[target] = append(variants[target], file)
+	}
+	for _, target := range targets {
+		target.Notes = append(target.Notes, variantNote(variants[target]))
+	}
+
+	// Stand-ins were appended last; restore the walk order.
+	order := make(map[*File]int, len(files))
+	for i, file := range files {
+		order[file] = i
+	}
+	sort.SliceStable(kept, func(i, j int) bool { return order[kept[i]] < order[kept[j]] })
+	return kept
+}
+
+// variantTarget picks the file of kept that notes the excluded file.
+func variantTarget(kept []*File, excluded *File) *File {
+	dir := filepath.Dir(excluded.Path)
+	stem, _ := fileStem(filepath.Base(excluded.Path))
+	var target *File
+	for _, file := range kept {
+		if filepath.Dir(file.Path) != dir || file.Package != excluded.Package {
+			continue
+		}
+		if candidate, _ := fileStem(filepath.Base(file.Path)); candidate == stem {
+			return file
+		}
+		if target == nil {
+			target = file
+		}
+	}
+	return target
+}
+
+// variantNote reads like "also has darwin, windows variants (a_darwin.go,
+// a_windows.go)".
+func variantNote(variants []*File) string {
+	var labels, names []string
+	seen := make(map[string]bool)
+	for _, v := range variants {
+		if !seen[v.excluded] {
+			seen[v.excluded] = true
+			labels = append(labels, v.excluded)
+		}
+		names = append(names, filepath.Base(v.Path))
+	}
+	sort.Strings(labels)
+	noun := "variant"
+	if len(labels) > 1 {
+		noun = "variants"
+	}
+	return "also has " + strings.Join(labels, ", ") + " " + noun + " (" + strings.Join(names, ", ") + ")"
+}
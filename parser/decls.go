@@ -0,0 +1,160 @@
+package parser
+
+import (
+	"go/ast"
+	"go/token"
+	"strings"
+)
+
+// maxValueLength is the longest literal value shown for untyped constants and
+// error sentinels before it is elided.
+const maxValueLength = 60
+
+// formatConstDecl compacts a const declaration to its names grouped by type,
+// e.g. "const (StateUnknown, StateHealthy, StateError ProjectState)". Untyped
+// constants keep short literal values since those are what callers rely on.
+func (p *Parser) formatConstDecl(fset *token.FileSet, decl *ast.GenDecl) (Symbol, bool) {
+	var groups []string
+	var names []string
+	var current []string
+	currentType := ""
+	flush := func() {
+		if len(current) > 0 {
+			groups = append(groups, strings.Join(current, ", ")+" "+currentType)
+			current = nil
+		}
+	}
+
+	// implicitType carries the type of the previous spec across implicit
+	// repetitions, as in iota enums.
+	implicitType := ""
+	for _, spec := range decl.Specs {
+		vs, ok := spec.(*ast.ValueSpec)
+		if !ok {
+			continue
+		}
+		typ := ""
+		switch {
+		case vs.Type != nil:
+			typ = p.formatNode(fset, vs.Type)
+		case len(vs.Values) == 0:
+			typ = implicitType
+		}
+		if len(vs.Values) > 0 || vs.Type != nil {
+			implicitType = typ
+		}
+
+		for i, name := range vs.Names {
+			if name.Name == "_" || p.apiOnly() && !name.IsExported() {
+				continue
+			}
+			names = append(names, name.Name)
+			if typ == "" {
+				flush()
+				untyped := name.Name
+				if i < len(vs.Values) {
+					if lit, ok := vs.Values[i].(*ast.BasicLit); ok && len(lit.Value) <= maxValueLength {
+						untyped += " = " + lit.Value
+					}
+				}
+				groups = append(groups, untyped)
+				continue
+			}
+			if typ != currentType {
+				flush()
+				currentType = typ
+			}
+			current = append(current, name.Name)
+		}
+	}
+	flush()
+
+	if len(groups) == 0 {
+		return Symbol{}, false
+	}
+	signature := "const " + groups[0]
+	if len(groups) > 1 || len(names) > 1 && decl.Lparen.IsValid() {
+		signature = "const (" + strings.Join(groups, "; ") + ")"
+	}
+	return Symbol{
+		Kind:      "const",
+		Name:      strings.Join(names, ", "),
+		Signature: signature,
+		Line:      fset.Position(decl.Pos()).Line,
+	}, true
+}
+
+// formatVarSpec renders package-level variables with their type. Error
+// sentinels and other calls keep their constructor so the model can reuse them
+// instead of inventing new ones.
+func (p *Parser) formatVarSpec(fset *token.FileSet, vs *ast.ValueSpec) []Symbol {
+	var symbols []Symbol
+	for i, name := range vs.Names {
+		if name.Name == "_" || p.apiOnly() && !name.IsExported() {
+			continue
+		}
+		signature := "var " + name.Name
+		switch {
+		case vs.Type != nil:
+			signature += " " + singleLine(p.formatNode(fset, vs.Type))
+		case i < len(vs.Values):
+			signature += p.formatVarValue(fset, vs.Values[i])
+		}
+		symbols = append(symbols, Symbol{
+			Kind:      "var",
+			Name:      name.Name,
+			Signature: signature,
+			Line:      fset.Position(name.Pos()).Line,
+		})
+	}
+	return symbols
+}
+
+// formatVarValue describes the initializer of an untyped variable as briefly as
+// possible: the full expression for short calls such as errors.New("..."), the
+// type of composite literals, and the callee for longer calls.
+func (p *Parser) formatVarValue(fset *token.FileSet, value ast.Expr) string {
+	switch v := value.(type) {
+	case *ast.CallExpr:
+		if call := p.formatNode(fset, v); len(call) <= maxValueLength && !strings.Contains(call, "\n") {
+			return " = " + call
+		}
+		return " = " + p.formatNode(fset, v.Fun) + "(...)"
+	case *ast.CompositeLit:
+		if v.Type != nil {
+			return " " + singleLine(p.formatNode(fset, v.Type))
+		}
+	case *ast.UnaryExpr:
+		if lit, ok := v.X.(*ast.CompositeLit); ok && v.Op == token.AND && lit.Type != nil {
+			return " *" + singleLine(p.formatNode(fset, lit.Type))
+		}
+	case *ast.FuncLit:
+		return " " + p.formatNode(fset, v.Type)
+	case *ast.BasicLit:
+		if len(v.Value) <= maxValueLength {
+			return " = " + v.Value
+		}
+	}
+	return ""
+}
+
+// formatTypeSpec renders named types that are not structs or interfaces, such
+// as "type Mode string", function types and aliases.
+func (p *Parser) formatTypeSpec(fset *token.FileSet, decl *ast.GenDecl, ts *ast.TypeSpec) Symbol {
+	return Symbol{
+		Kind:      "type",
+		Name:      ts.Name.Name,
+		Signature: "type " + p.formatNode(fset, ts),
+		Doc:       p.docText(typeDoc(decl, ts)),
+		Line:      fset.Position(ts.Pos()).Line,
+	}
+}
+
+// singleLine elides the body of a multi-line type such as an anonymous struct,
+// keeping variable summaries to one line.
+func singleLine(s string) string {
+	if i := strings.Index(s, "\n"); i >= 0 {
+		return s[:i] + "...}"
+	}
+	return s
+}
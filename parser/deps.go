@@ -0,0 +1,347 @@
+package parser
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+)
+
+// majorVersion matches the version element of import paths such as
+// github.com/x/y/v2.
+var majorVersion = regexp.MustCompile(`^v[0-9]+$`)
+
+// isThirdParty reports whether importPath is neither internal nor part of the
+// standard library, whose import paths have no dot in their first element.
+func isThirdParty(importPath string, internal []string) bool {
+	first, _, _ := strings.Cut(importPath, "/")
+	return strings.Contains(first, ".") && !isInternal(importPath, internal)
+}
+
+// packageNames guesses the names a package is referred to by when its import
+// is not renamed: the last path element, without a major version element, a
+// ".vN" suffix or a "go-" prefix or "-go" suffix.
+func packageNames(importPath string) []string {
+	elems := strings.Split(importPath, "/")
+	last := elems[len(elems)-1]
+	if majorVersion.MatchString(last) && len(elems) > 1 {
+		last = elems[len(elems)-2]
+	}
+	names := []string{last}
+	if i := strings.Index(last, ".v"); i > 0 {
+		names = append(names, last[:i])
+	}
+	trimmed := strings.TrimSuffix(strings.TrimPrefix(last, "go-"), "-go")
+	names = append(names, strings.ReplaceAll(trimmed, "-", ""))
+	return names
+}
+
+// collectUses records the exported identifiers node references from
+// third-party packages, keyed by import path, and the exported names it
+// selects on values, which pick the methods listed for third-party types.
+func collectUses(node *ast.File, internal []string) (map[string][]string, []string) {
+	imported := make(map[string]string)
+	for _, spec := range node.Imports {
+		importPath := strings.Trim(spec.Path.Value, "\"")
+		if !isThirdParty(importPath, internal) {
+			continue
+		}
+		if spec.Name != nil {
+			imported[spec.Name.Name] = importPath
+			continue
+		}
+		for _, name := range packageNames(importPath) {
+			imported[name] = importPath
+		}
+	}
+	if len(imported) == 0 {
+		return nil, nil
+	}
+
+	uses := make(map[string][]string)
+	seen := make(map[string]bool)
+	var selectors []string
+	ast.Inspect(node, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok || !sel.Sel.IsExported() {
+			return true
+		}
+		if pkg, ok := sel.X.(*ast.Ident); ok && imported[pkg.Name] != "" {
+			importPath := imported[pkg.Name]
+			if key := importPath + "." + sel.Sel.Name; !seen[key] {
+				seen[key] = true
+				uses[importPath] = append(uses[importPath], sel.Sel.Name)
+			}
+			return true
+		}
+		if !seen[sel.Sel.Name] {
+			seen[sel.Sel.Name] = true
+			selectors = append(selectors, sel.Sel.Name)
+		}
+		return true
+	})
+	return uses, selectors
+}
+
+// parseDependencies renders the "dependencies" section: for every third-party
+// package the project imports, the signatures of the exported identifiers it
+// references, read from vendor/ or the module cache without network access.
+// The internal packages kept only as dependencies of a restricted project do
+// not count.
+func (p *Parser) parseDependencies(project *Project) string {
+	uses := make(map[string]map[string]bool)
+	users := make(map[string]string) // import path to the module importing it
+	selectors := make(map[string]bool)
+	for _, file := range project.Files {
+		if file.Dependency {
+			continue
+		}
+		for importPath, names := range file.Uses {
+			if uses[importPath] == nil {
+				uses[importPath] = make(map[string]bool)
+				users[importPath] = file.Module
+			}
+			for _, name := range names {
+				uses[importPath][name] = true
+			}
+		}
+		for _, name := range file.Selectors {
+			selectors[name] = true
+		}
+	}
+	if len(uses) == 0 {
+		return ""
+	}
+
+	importPaths := make([]string, 0, len(uses))
+	for importPath := range uses {
+		importPaths = append(importPaths, importPath)
+	}
+	sort.Strings(importPaths)
+
+	resolver := &depResolver{modules: project.Modules, modFiles: make(map[string]*modfile.File)}
+	var builder strings.Builder
+	builder.WriteString("dependencies (third-party APIs referenced by the project):\n")
+	for _, importPath := range importPaths {
+		dir, version := resolver.resolve(users[importPath], importPath)
+		if dir == "" {
+			builder.WriteString("package " + importPath + ": not found in vendor/ or the module cache, run go mod download\n\n")
+			continue
+		}
+		signatures, err := p.dependencyAPI(dir, uses[importPath], selectors)
+		if err != nil {
+			builder.WriteString("package " + importPath + ": " + err.Error() + "\n\n")
+			continue
+		}
+		builder.WriteString("package " + importPath + " (" + version + ")\n")
+		for _, signature := range signatures {
+			builder.WriteString(signature + "\n")
+		}
+		builder.WriteString("\n")
+	}
+	return builder.String()
+}
+
+// depResolver finds the directory of third-party packages for the modules of
+// the project.
+type depResolver struct {
+	modules  []*Module
+	modFiles map[string]*modfile.File
+}
+
+// resolve returns the directory of importPath as required by the module at
+// modulePath, and the module version it comes from.
+func (r *depResolver) resolve(modulePath, importPath string) (string, string) {
+	var m *Module
+	for _, candidate := range r.modules {
+		if candidate.Path == modulePath {
+			m = candidate
+		}
+	}
+	if m == nil {
+		return "", ""
+	}
+
+	vendored := filepath.Join(m.abs, "vendor", filepath.FromSlash(importPath))
+	if isDir(vendored) {
+		return vendored, "vendor"
+	}
+
+	modFile := r.modFile(m)
+	if modFile == nil {
+		return "", ""
+	}
+	var required *module.Version
+	for _, req := range modFile.Require {
+		if (importPath == req.Mod.Path || strings.HasPrefix(importPath, req.Mod.Path+"/")) &&
+			(required == nil || len(req.Mod.Path) > len(required.Path)) {
+			required = &req.Mod
+		}
+	}
+	if required == nil {
+		return "", ""
+	}
+	rest := strings.TrimPrefix(strings.TrimPrefix(importPath, required.Path), "/")
+	version := required.Path + " " + required.Version
+
+	target := *required
+	for _, rep := range modFile.Replace {
+		if rep.Old.Path != required.Path || rep.Old.Version != "" && rep.Old.Version != required.Version {
+			continue
+		}
+		if modfile.IsDirectoryPath(rep.New.Path) {
+			dir := rep.New.Path
+			if !filepath.IsAbs(dir) {
+				dir = filepath.Join(m.abs, dir)
+			}
+			return filepath.Join(dir, filepath.FromSlash(rest)), version + " => " + rep.New.Path
+		}
+		target = rep.New
+		version += " => " + rep.New.Path + " " + rep.New.Version
+	}
+
+	escapedPath, err := module.EscapePath(target.Path)
+	if err != nil {
+		return "", ""
+	}
+	escapedVersion, err := module.EscapeVersion(target.Version)
+	if err != nil {
+		return "", ""
+	}
+	dir := filepath.Join(modCacheDir(), escapedPath+"@"+escapedVersion, filepath.FromSlash(rest))
+	if !isDir(dir) {
+		return "", ""
+	}
+	return dir, version
+}
+
+func (r *depResolver) modFile(m *Module) *modfile.File {
+	if f, ok := r.modFiles[m.abs]; ok {
+		return f
+	}
+	goMod := filepath.Join(m.abs, "go.mod")
+	var f *modfile.File
+	if data, err := os.ReadFile(goMod); err == nil {
+		f, _ = modfile.Parse(goMod, data, nil)
+	}
+	r.modFiles[m.abs] = f
+	return f
+}
+
+// modCacheDir returns GOMODCACHE, which defaults to pkg/mod in the first
+// GOPATH entry.
+func modCacheDir() string {
+	if dir := os.Getenv("GOMODCACHE"); dir != "" {
+		return dir
+	}
+	gopath := filepath.SplitList(build.Default.GOPATH)
+	if len(gopath) == 0 {
+		return ""
+	}
+	return filepath.Join(gopath[0], "pkg", "mod")
+}
+
+func isDir(dir string) bool {
+	info, err := os.Stat(dir)
+	return err == nil && info.IsDir()
+}
+
+// dependencyAPI parses the package in dir and returns the signatures of the
+// names it declares, each type followed by its methods that are selected
+// somewhere in the project.
+func (p *Parser) dependencyAPI(dir string, names, selectors map[string]bool) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	type declaration struct {
+		signature string
+		typeName  string // set for types, whose methods follow them
+	}
+	var found []declaration
+	methods := make(map[string][]string)
+	ctxt := p.buildContext()
+	fset := token.NewFileSet()
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || path.Ext(name) != ".go" || isTestFile(name) {
+			continue
+		}
+		if match, err := ctxt.MatchFile(dir, name); err != nil || !match {
+			continue
+		}
+		node, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.ParseComments|parser.SkipObjectResolution)
+		if err != nil {
+			continue
+		}
+		text := func(doc *ast.CommentGroup, signature string) string {
+			return symbolText(Symbol{Signature: signature, Doc: p.docText(doc)})
+		}
+		for _, d := range node.Decls {
+			switch x := d.(type) {
+			case *ast.FuncDecl:
+				if x.Recv != nil && len(x.Recv.List) > 0 {
+					if x.Name.IsExported() && selectors[x.Name.Name] {
+						recv := receiverName(x.Recv.List[0].Type)
+						methods[recv] = append(methods[recv], text(x.Doc, p.formatFunctionSignature(fset, x)))
+					}
+				} else if names[x.Name.Name] {
+					found = append(found, declaration{signature: text(x.Doc, p.formatFunctionSignature(fset, x))})
+				}
+			case *ast.GenDecl:
+				for _, spec := range x.Specs {
+					switch s := spec.(type) {
+					case *ast.TypeSpec:
+						if !names[s.Name.Name] {
+							continue
+						}
+						signature := "type " + p.formatNode(fset, s)
+						switch s.Type.(type) {
+						case *ast.StructType, *ast.InterfaceType:
+							signature = p.formatTypeWithDocs(fset, exportedSpec(s))
+						}
+						found = append(found, declaration{signature: text(typeDoc(x, s), signature), typeName: s.Name.Name})
+					case *ast.ValueSpec:
+						doc := s.Doc
+						if doc == nil && len(x.Specs) == 1 {
+							doc = x.Doc
+						}
+						for _, ident := range s.Names {
+							if !names[ident.Name] {
+								continue
+							}
+							signature := x.Tok.String() + " " + ident.Name
+							if s.Type != nil {
+								signature += " " + singleLine(p.formatNode(fset, s.Type))
+							}
+							found = append(found, declaration{signature: text(doc, signature)})
+						}
+					}
+				}
+			}
+		}
+	}
+	if len(found) == 0 {
+		return nil, fmt.Errorf("none of the referenced names were found in %s", dir)
+	}
+
+	var signatures []string
+	for _, d := range found {
+		signatures = append(signatures, d.signature)
+		if d.typeName != "" {
+			signatures = append(signatures, methods[d.typeName]...)
+		}
+	}
+	return signatures, nil
+}
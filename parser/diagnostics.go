@@ -0,0 +1,117 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"go/scanner"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxRenderedDiagnostics caps how many diagnostics are written into the
+// blueprint; callers still receive the full list.
+const maxRenderedDiagnostics = 20
+
+// Diagnostic severities. An error means the file could not be summarized and
+// is left out, such as a syntax error in a file that is being edited. A
+// warning is a problem in a file that is still summarized, such as a type
+// error.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// typeCheckFailed prefixes the warning reported when go/packages cannot load a
+// module at all, as opposed to a type error in one of its files.
+const typeCheckFailed = "type check failed: "
+
+// Diagnostic describes a problem found while summarizing a file.
+type Diagnostic struct {
+	File     string `json:"file"`           // relative to the project root
+	Line     int    `json:"line,omitempty"` // zero when the position is unknown
+	Column   int    `json:"column,omitempty"`
+	Message  string `json:"message"`
+	Severity string `json:"severity"` // SeverityError or SeverityWarning
+}
+
+// LeftOut reports whether the file of d is missing from the summary.
+func (d Diagnostic) LeftOut() bool {
+	return d.Severity != SeverityWarning
+}
+
+func (d Diagnostic) String() string {
+	if d.Line == 0 {
+		return d.File + ": " + d.Message
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", d.File, d.Line, d.Column, d.Message)
+}
+
+// positionPrefix matches the "file:line:col: " prefix used by the proto parser.
+var positionPrefix = regexp.MustCompile(`^(?:[^:]*:)?(\d+):(\d+):\s*`)
+
+// diagnosticsFromError converts a parse error for the file at rel into
+// diagnostics, one per reported position when the error carries several.
+func diagnosticsFromError(rel string, err error) []Diagnostic {
+	var list scanner.ErrorList
+	if errors.As(err, &list) {
+		var diagnostics []Diagnostic
+		for _, e := range list {
+			diagnostics = append(diagnostics, Diagnostic{
+				File:     rel,
+				Line:     e.Pos.Line,
+				Column:   e.Pos.Column,
+				Message:  e.Msg,
+				Severity: SeverityError,
+			})
+		}
+		return diagnostics
+	}
+
+	d := Diagnostic{File: rel, Message: err.Error(), Severity: SeverityError}
+	if m := positionPrefix.FindStringSubmatch(d.Message); m != nil {
+		d.Line, _ = strconv.Atoi(m[1])
+		d.Column, _ = strconv.Atoi(m[2])
+		d.Message = strings.TrimPrefix(d.Message, m[0])
+	}
+	return []Diagnostic{d}
+}
+
+// renderDiagnostics writes the diagnostics section of the blueprint, with the
+// files left out of the summary listed apart from the warnings about files
+// that are still in it. Modules that could not be type-checked at all are
+// listed on their own. Each list shows at most limit diagnostics.
+func renderDiagnostics(diagnostics []Diagnostic, limit int) string {
+	var errs, warnings, failures []Diagnostic
+	for _, d := range diagnostics {
+		switch {
+		case d.LeftOut():
+			errs = append(errs, d)
+		case strings.HasPrefix(d.Message, typeCheckFailed):
+			failures = append(failures, d)
+		default:
+			warnings = append(warnings, d)
+		}
+	}
+	return renderDiagnosticList("diagnostics (files with syntax errors are left out of the summary above):\n", errs, limit) +
+		renderDiagnosticList("type errors (these files are still summarized above):\n", warnings, limit) +
+		renderDiagnosticList("type check failures (these modules are summarized above without their types):\n", failures, limit)
+}
+
+// renderDiagnosticList writes header followed by at most limit of the
+// diagnostics, or nothing if there are none.
+func renderDiagnosticList(header string, diagnostics []Diagnostic, limit int) string {
+	if len(diagnostics) == 0 {
+		return ""
+	}
+	var builder strings.Builder
+	builder.WriteString(header)
+	for i, d := range diagnostics {
+		if i == limit {
+			builder.WriteString(fmt.Sprintf("(%d more)\n", len(diagnostics)-i))
+			break
+		}
+		builder.WriteString(d.String() + "\n")
+	}
+	return builder.String()
+}
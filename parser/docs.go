@@ -0,0 +1,227 @@
+package parser
+
+import (
+	"go/ast"
+	"go/printer"
+	"go/token"
+	"sort"
+	"strings"
+)
+
+// Doc comment modes for the "docs" context setting.
+const (
+	DocsNone          = "none"
+	DocsFirstSentence = "first-sentence"
+	DocsFull          = "full"
+)
+
+// docsMode returns the configured doc comment mode, defaulting to the first
+// sentence, which keeps the intent of a declaration at a small token cost.
+func (p *Parser) docsMode() string {
+	switch mode := p.config.Context.Docs; mode {
+	case DocsNone, DocsFull:
+		return mode
+	}
+	return DocsFirstSentence
+}
+
+// docText returns the part of the comment group shown in the blueprint.
+func (p *Parser) docText(group *ast.CommentGroup) string {
+	if group == nil {
+		return ""
+	}
+	text := strings.TrimSpace(group.Text())
+	switch p.docsMode() {
+	case DocsNone:
+		return ""
+	case DocsFull:
+		return text
+	}
+	return firstSentence(text)
+}
+
+// firstSentence returns the first sentence of the first paragraph of text,
+// joined onto one line. A sentence ends at a period, question mark or
+// exclamation mark followed by a space, except for an ellipsis such as the
+// one in "Code generated ... DO NOT EDIT" and the periods of single-letter
+// abbreviations such as "e.g.", where go/doc's synopsis would cut.
+func firstSentence(text string) string {
+	paragraph, _, _ := strings.Cut(text, "\n\n")
+	paragraph = strings.Join(strings.Fields(paragraph), " ")
+	for i := 0; i < len(paragraph); i++ {
+		c := paragraph[i]
+		if c != '.' && c != '?' && c != '!' {
+			continue
+		}
+		if i+1 < len(paragraph) && paragraph[i+1] != ' ' {
+			continue
+		}
+		if c == '.' && i > 0 && paragraph[i-1] == '.' {
+			continue
+		}
+		if c == '.' && i > 0 && isLetter(paragraph[i-1]) && (i == 1 || !isLetter(paragraph[i-2])) {
+			continue
+		}
+		return paragraph[:i+1]
+	}
+	return paragraph
+}
+
+func isLetter(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+// typeDoc returns the doc comment of a type spec, which sits on the
+// declaration when the type is not declared in a group.
+func typeDoc(decl *ast.GenDecl, spec *ast.TypeSpec) *ast.CommentGroup {
+	if spec.Doc == nil && len(decl.Specs) == 1 {
+		return decl.Doc
+	}
+	return spec.Doc
+}
+
+// formatTypeWithDocs prints a struct or interface type with the doc and line
+// comments of its fields and methods, nested ones included, reduced to the
+// configured mode. Only exported fields and methods are printed in API-only
+// mode.
+func (p *Parser) formatTypeWithDocs(fset *token.FileSet, spec *ast.TypeSpec) string {
+	if p.apiOnly() {
+		spec = exportedSpec(spec)
+	}
+	if p.docsMode() == DocsNone {
+		return p.formatNode(fset, spec)
+	}
+
+	var comments []*ast.CommentGroup
+	ast.Inspect(spec.Type, func(n ast.Node) bool {
+		if field, ok := n.(*ast.Field); ok {
+			for _, group := range []*ast.CommentGroup{field.Doc, field.Comment} {
+				if text := p.docText(group); text != "" {
+					comments = append(comments, p.commentGroup(group, text))
+				}
+			}
+		}
+		return true
+	})
+	if len(comments) == 0 {
+		return p.formatNode(fset, spec)
+	}
+	sort.Slice(comments, func(i, j int) bool { return comments[i].Pos() < comments[j].Pos() })
+
+	var buf strings.Builder
+	printer.Fprint(&buf, fset, &printer.CommentedNode{Node: withoutComments(spec), Comments: comments})
+	if p.docsMode() == DocsFull {
+		return buf.String()
+	}
+	// A shortened doc comment keeps the position of its group's last line, so
+	// the printer sees a gap before it where the rest of the group was and
+	// writes a blank line there.
+	lines := strings.Split(buf.String(), "\n")
+	kept := lines[:0]
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" && i+1 < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i+1]), "//") {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return strings.Join(kept, "\n")
+}
+
+// withoutComments returns a copy of node without the doc and line comments of
+// its fields, which the printer would otherwise write in full. Nodes other
+// than type specs and expressions are returned unchanged.
+func withoutComments(node ast.Node) ast.Node {
+	switch n := node.(type) {
+	case *ast.TypeSpec:
+		copied := *n
+		copied.Doc, copied.Comment = nil, nil
+		copied.TypeParams = fieldsWithoutComments(n.TypeParams)
+		copied.Type = exprWithoutComments(n.Type)
+		return &copied
+	case ast.Expr:
+		return exprWithoutComments(n)
+	}
+	return node
+}
+
+// exprWithoutComments copies a type expression down to every field list in
+// it, clearing the comments of the fields.
+func exprWithoutComments(expr ast.Expr) ast.Expr {
+	switch t := expr.(type) {
+	case *ast.StructType:
+		copied := *t
+		copied.Fields = fieldsWithoutComments(t.Fields)
+		return &copied
+	case *ast.InterfaceType:
+		copied := *t
+		copied.Methods = fieldsWithoutComments(t.Methods)
+		return &copied
+	case *ast.FuncType:
+		copied := *t
+		copied.TypeParams = fieldsWithoutComments(t.TypeParams)
+		copied.Params = fieldsWithoutComments(t.Params)
+		copied.Results = fieldsWithoutComments(t.Results)
+		return &copied
+	case *ast.StarExpr:
+		copied := *t
+		copied.X = exprWithoutComments(t.X)
+		return &copied
+	case *ast.ParenExpr:
+		copied := *t
+		copied.X = exprWithoutComments(t.X)
+		return &copied
+	case *ast.ArrayType:
+		copied := *t
+		copied.Elt = exprWithoutComments(t.Elt)
+		return &copied
+	case *ast.MapType:
+		copied := *t
+		copied.Key = exprWithoutComments(t.Key)
+		copied.Value = exprWithoutComments(t.Value)
+		return &copied
+	case *ast.ChanType:
+		copied := *t
+		copied.Value = exprWithoutComments(t.Value)
+		return &copied
+	}
+	return expr
+}
+
+func fieldsWithoutComments(fields *ast.FieldList) *ast.FieldList {
+	if fields == nil {
+		return nil
+	}
+	copied := *fields
+	copied.List = make([]*ast.Field, len(fields.List))
+	for i, field := range fields.List {
+		f := *field
+		f.Doc, f.Comment = nil, nil
+		f.Type = exprWithoutComments(field.Type)
+		copied.List[i] = &f
+	}
+	return &copied
+}
+
+// commentGroup returns group itself in full mode, or a single comment holding
+// text. The comment takes the position of the group's last line so the printer
+// keeps it next to the field it documents.
+func (p *Parser) commentGroup(group *ast.CommentGroup, text string) *ast.CommentGroup {
+	if p.docsMode() == DocsFull {
+		return group
+	}
+	last := group.List[len(group.List)-1]
+	return &ast.CommentGroup{List: []*ast.Comment{{Slash: last.Slash, Text: "// " + text}}}
+}
+
+// symbolText is a symbol as written in the blueprint: its doc comment, if
+// any, followed by its signature.
+func symbolText(sym Symbol) string {
+	if sym.Doc == "" {
+		return sym.Signature
+	}
+	lines := strings.Split(sym.Doc, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight("// "+line, " ")
+	}
+	return strings.Join(lines, "\n") + "\n" + sym.Signature
+}
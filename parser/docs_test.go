@@ -0,0 +1,59 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/piqoni/vogte/config"
+)
+
+func TestFirstSentence(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Load reads the file. It returns nil when missing.", "Load reads the file."},
+		{"SkipGenerated leaves files with a \"Code generated ... DO NOT EDIT\"\nheader out. Otherwise they take a line.", "SkipGenerated leaves files with a \"Code generated ... DO NOT EDIT\" header out."},
+		{"Open accepts a path, e.g. ./data, and opens it. Errors are wrapped.", "Open accepts a path, e.g. ./data, and opens it."},
+		{"Ready reports whether it started? Mostly.", "Ready reports whether it started?"},
+		{"No sentence end\n\nSecond paragraph.", "No sentence end"},
+		{"Version is v1.2 for now.", "Version is v1.2 for now."},
+	}
+	for _, tt := range tests {
+		if got := firstSentence(tt.in); got != tt.want {
+			t.Errorf("firstSentence(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFieldDocsWithoutBlankLines(t *testing.T) {
+	dir := t.TempDir()
+	src := `package store
+
+// Options configure a store.
+type Options struct {
+	// Path is where records are kept. It is created when missing.
+	Path string
+	Size int // entries kept in memory
+	// Sync flushes every write.
+	// It is slow.
+	Sync bool
+}
+`
+	writeFiles(t, dir, map[string]string{"store.go": src})
+	project, err := New(&config.Config{}).Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature := project.Files[0].Symbols[0].Signature
+	for _, want := range []string{"// Path is where records are kept.\n", "// entries kept in memory\n", "// Sync flushes every write.\n"} {
+		if !strings.Contains(signature, want) {
+			t.Errorf("signature is missing %q:\n%s", want, signature)
+		}
+	}
+	for _, line := range strings.Split(signature, "\n") {
+		if strings.TrimSpace(line) == "" {
+			t.Errorf("signature has a blank line:\n%s", signature)
+			break
+		}
+	}
+}
@@ -0,0 +1,329 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Format selects how the blueprint is written.
+type Format string
+
+const (
+	FormatText     Format = "text"
+	FormatJSON     Format = "json"
+	FormatMarkdown Format = "markdown"
+	FormatXML      Format = "xml"
+)
+
+// ParseFormat validates a format name given on the command line.
+func ParseFormat(name string) (Format, error) {
+	switch format := Format(strings.ToLower(name)); format {
+	case FormatText, FormatJSON, FormatMarkdown, FormatXML:
+		return format, nil
+	case "md":
+		return FormatMarkdown, nil
+	}
+	return "", fmt.Errorf("unknown format %q (want text, json, markdown or xml)", name)
+}
+
+// Render writes the project in the given format. The text, markdown and XML
+// forms are ranked against task and compacted to budget tokens like Compact;
+// JSON is meant for tools and always contains every symbol.
+func (p *Parser) Render(project *Project, format Format, task string, budget int) (string, error) {
+	switch format {
+	case FormatText, "":
+		return p.compact(project, task, budget, textRenderer{}), nil
+	case FormatMarkdown:
+		return p.compact(project, task, budget, markdownRenderer{}), nil
+	case FormatXML:
+		return p.compact(project, task, budget, xmlRenderer{}), nil
+	case FormatJSON:
+		var buf strings.Builder
+		encoder := json.NewEncoder(&buf)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(project.export()); err != nil {
+			return "", fmt.Errorf("could not encode project: %w", err)
+		}
+		return buf.String(), nil
+	}
+	return "", fmt.Errorf("unknown format %q", format)
+}
+
+// renderer writes the parts of a blueprint in one output format. Each method
+// returns the complete text of its part, so its token cost can be estimated
+// before it is written.
+type renderer interface {
+	begin() string
+	end() string
+	workspace(w *Workspace) string
+	scope(patterns []string) string
+	module(m *Module, body string) string
+	file(f *File, symbols []Symbol) string
+	// section wraps a trailing part of the blueprint: omitted packages, types
+	// or diagnostics. It returns an empty string for an empty body.
+	section(name, body string) string
+}
+
+// imports returns the imports written on the entry of f: its internal Go
+// imports, or the files a proto file imports.
+func (f *File) imports() []string {
+	return append(f.Imports[:len(f.Imports):len(f.Imports)], f.ProtoImports...)
+}
+
+// textRenderer writes the plain blueprint sent to the LLM.
+type textRenderer struct{}
+
+func (textRenderer) begin() string { return "" }
+func (textRenderer) end() string   { return "" }
+
+func (textRenderer) workspace(w *Workspace) string {
+	return "workspace: " + w.File + " (use " + strings.Join(w.Use, ", ") + ")\n"
+}
+
+func (textRenderer) scope(patterns []string) string {
+	return "scope: " + strings.Join(patterns, ", ") + " (other packages are left out, dependencies show exported signatures only)\n"
+}
+
+func (textRenderer) module(m *Module, body string) string {
+	var builder strings.Builder
+	builder.WriteString("module: " + m.Path + " (" + m.Dir + ")\n")
+	for _, line := range m.goModLines() {
+		builder.WriteString(line + "\n")
+	}
+	builder.WriteString(body)
+	return builder.String()
+}
+
+func (textRenderer) file(f *File, symbols []Symbol) string {
+	var builder strings.Builder
+	builder.WriteString("file: " + f.Path)
+	if f.Dependency {
+		builder.WriteString(" (dependency)")
+	}
+	builder.WriteString("\n")
+	if f.Package != "" {
+		builder.WriteString("package " + f.Package + "\n")
+	}
+	for _, imp := range f.imports() {
+		builder.WriteString("import " + imp + "\n")
+	}
+	for _, sym := range symbols {
+		builder.WriteString(symbolText(sym) + "\n")
+	}
+	for _, note := range f.Notes {
+		builder.WriteString("// " + note + "\n")
+	}
+	builder.WriteString("\n")
+	return builder.String()
+}
+
+func (textRenderer) section(name, body string) string { return body }
+
+// markdownRenderer writes one heading and code block per file, for reading or
+// pasting into documents.
+type markdownRenderer struct{}
+
+func (markdownRenderer) begin() string { return "" }
+func (markdownRenderer) end() string   { return "" }
+
+func (markdownRenderer) workspace(w *Workspace) string {
+	return "Workspace `" + w.File + "` (use " + strings.Join(w.Use, ", ") + ")\n\n"
+}
+
+func (markdownRenderer) scope(patterns []string) string {
+	return "Scope: `" + strings.Join(patterns, "`, `") + "` (other packages are left out, dependencies show exported signatures only)\n\n"
+}
+
+func (markdownRenderer) module(m *Module, body string) string {
+	header := "## Module `" + m.Path + "` (" + m.Dir + ")\n\n"
+	if lines := m.goModLines(); len(lines) > 0 {
+		header += "```go.mod\n" + strings.Join(lines, "\n") + "\n```\n\n"
+	}
+	return header + body
+}
+
+func (markdownRenderer) file(f *File, symbols []Symbol) string {
+	language := "go"
+	if path.Ext(f.Path) == ".proto" {
+		language = "proto"
+	}
+	var builder strings.Builder
+	builder.WriteString("### " + f.Path)
+	if f.Dependency {
+		builder.WriteString(" (dependency)")
+	}
+	builder.WriteString("\n\n```" + language + "\n")
+	if f.Package != "" {
+		builder.WriteString("package " + f.Package + "\n")
+	}
+	for _, imp := range f.imports() {
+		builder.WriteString("import " + imp + "\n")
+	}
+	for _, sym := range symbols {
+		builder.WriteString(symbolText(sym) + "\n")
+	}
+	for _, note := range f.Notes {
+		builder.WriteString("// " + note + "\n")
+	}
+	builder.WriteString("```\n\n")
+	return builder.String()
+}
+
+func (markdownRenderer) section(name, body string) string {
+	if body == "" {
+		return ""
+	}
+	return "## " + strings.ToUpper(name[:1]) + name[1:] + "\n\n```\n" + body + "```\n\n"
+}
+
+// xmlRenderer wraps every part in tags, which web chat UIs keep apart from
+// the surrounding prompt.
+type xmlRenderer struct{}
+
+var (
+	xmlText      = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	xmlAttribute = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;")
+)
+
+func (xmlRenderer) begin() string { return "<context>\n" }
+func (xmlRenderer) end() string   { return "</context>\n" }
+
+func (xmlRenderer) workspace(w *Workspace) string {
+	return `<workspace file="` + xmlAttribute.Replace(w.File) + `" use="` + xmlAttribute.Replace(strings.Join(w.Use, " ")) + "\"/>\n"
+}
+
+func (xmlRenderer) scope(patterns []string) string {
+	return `<scope packages="` + xmlAttribute.Replace(strings.Join(patterns, " ")) + "\"/>\n"
+}
+
+func (xmlRenderer) module(m *Module, body string) string {
+	header := `<module path="` + xmlAttribute.Replace(m.Path) + `" dir="` + xmlAttribute.Replace(m.Dir) + "\">\n"
+	if lines := m.goModLines(); len(lines) > 0 {
+		header += "<gomod>\n" + xmlText.Replace(strings.Join(lines, "\n")) + "\n</gomod>\n"
+	}
+	return header + body + "</module>\n"
+}
+
+func (xmlRenderer) file(f *File, symbols []Symbol) string {
+	var builder strings.Builder
+	builder.WriteString(`<file path="` + xmlAttribute.Replace(f.Path) + `"`)
+	if f.Package != "" {
+		builder.WriteString(` package="` + xmlAttribute.Replace(f.Package) + `"`)
+	}
+	if f.Dependency {
+		builder.WriteString(` dependency="true"`)
+	}
+	builder.WriteString(">\n")
+	for _, imp := range f.imports() {
+		builder.WriteString("import " + xmlText.Replace(imp) + "\n")
+	}
+	for _, sym := range symbols {
+		builder.WriteString(xmlText.Replace(symbolText(sym)) + "\n")
+	}
+	for _, note := range f.Notes {
+		builder.WriteString(xmlText.Replace("// "+note) + "\n")
+	}
+	builder.WriteString("</file>\n")
+	return builder.String()
+}
+
+func (xmlRenderer) section(name, body string) string {
+	if body == "" {
+		return ""
+	}
+	return "<" + name + ">\n" + xmlText.Replace(body) + "</" + name + ">\n"
+}
+
+// jsonProject is the schema of the JSON output: modules contain packages,
+// packages contain files and files contain symbols.
+type jsonProject struct {
+	Dir       string       `json:"dir"`
+	Workspace *Workspace   `json:"workspace,omitempty"`
+	Scope     []string     `json:"scope,omitempty"`
+	Modules   []jsonModule `json:"modules"`
+	// Imports maps package directories to the internal packages they import,
+	// and Callers maps exported functions to their direct callers.
+	Imports      map[string][]string `json:"imports,omitempty"`
+	Callers      map[string][]string `json:"callers,omitempty"`
+	Types        string              `json:"types,omitempty"`
+	Dependencies string              `json:"dependencies,omitempty"`
+	Diagnostics  []Diagnostic        `json:"diagnostics,omitempty"`
+}
+
+type jsonModule struct {
+	Path      string        `json:"path"` // empty for files outside any module
+	Dir       string        `json:"dir"`
+	Go        string        `json:"go,omitempty"`
+	Toolchain string        `json:"toolchain,omitempty"`
+	Require   []string      `json:"require,omitempty"`
+	Replace   []string      `json:"replace,omitempty"`
+	Packages  []jsonPackage `json:"packages"`
+}
+
+type jsonPackage struct {
+	Name     string     `json:"name"`
+	Dir      string     `json:"dir"`
+	Exported int        `json:"exported"` // number of exported symbols
+	Files    []jsonFile `json:"files"`
+}
+
+type jsonFile struct {
+	Path       string   `json:"path"`
+	Dependency bool     `json:"dependency,omitempty"`
+	Generator  string   `json:"generator,omitempty"`
+	Imports    []string `json:"imports,omitempty"`
+	Symbols    []Symbol `json:"symbols"`
+	Notes      []string `json:"notes,omitempty"`
+}
+
+// export groups the project's files by module and package for the JSON form.
+func (proj *Project) export() jsonProject {
+	out := jsonProject{Dir: proj.Dir, Workspace: proj.Workspace, Scope: proj.Scope, Modules: []jsonModule{}}
+	add := func(m jsonModule) {
+		if len(m.Packages) > 0 {
+			out.Modules = append(out.Modules, m)
+		}
+	}
+	add(proj.exportModule(jsonModule{Dir: "."}))
+	for _, m := range proj.Modules {
+		add(proj.exportModule(jsonModule{Path: m.Path, Dir: m.Dir, Go: m.Go, Toolchain: m.Toolchain, Require: m.Require, Replace: m.Replace}))
+	}
+	out.Imports = proj.importGraph()
+	if proj.callers {
+		out.Callers = proj.callerGraph()
+	}
+	out.Types = proj.Types
+	out.Dependencies = proj.Dependencies
+	out.Diagnostics = proj.Diagnostics
+	return out
+}
+
+func (proj *Project) exportModule(m jsonModule) jsonModule {
+	counts := proj.exportedCounts()
+	packages := make(map[string]int)
+	for _, file := range proj.Files {
+		if file.Module != m.Path {
+			continue
+		}
+		key := packageKey(file)
+		i, ok := packages[key]
+		if !ok {
+			i = len(m.Packages)
+			packages[key] = i
+			m.Packages = append(m.Packages, jsonPackage{Name: file.Package, Dir: path.Dir(file.Path), Exported: counts[key]})
+		}
+
+		f := jsonFile{Path: file.Path, Dependency: file.Dependency, Generator: file.Generator, Symbols: file.Symbols, Notes: file.Notes}
+		for _, imp := range file.imports() {
+			f.Imports = append(f.Imports, strings.Trim(imp, `"`))
+		}
+		if f.Symbols == nil {
+			f.Symbols = []Symbol{}
+		}
+		m.Packages[i].Files = append(m.Packages[i].Files, f)
+	}
+	return m
+}
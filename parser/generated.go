@@ -0,0 +1,84 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/token"
+	"regexp"
+	"strings"
+)
+
+// generatedHeader matches the standard marker described in
+// https://go.dev/s/generatedcode, e.g. "// Code generated by sqlc. DO NOT EDIT.".
+var generatedHeader = regexp.MustCompile(`^// Code generated (.*)DO NOT EDIT\.$`)
+
+// GeneratedBy reports whether src carries the "Code generated ... DO NOT EDIT."
+// header before the package clause, and if so which tool produced it. The tool
+// is "unknown" when the header does not name one.
+func GeneratedBy(src []byte) (string, bool) {
+	scanner := bufio.NewScanner(bytes.NewReader(src))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	inBlock := false
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case inBlock:
+			if strings.Contains(line, "*/") {
+				inBlock = false
+			}
+		case line == "":
+		case strings.HasPrefix(line, "/*"):
+			inBlock = !strings.Contains(line, "*/")
+		case strings.HasPrefix(line, "//"):
+			if m := generatedHeader.FindStringSubmatch(line); m != nil {
+				return generatorName(m[1]), true
+			}
+		default:
+			// The marker must appear before the first non-comment text.
+			return "", false
+		}
+	}
+	return "", false
+}
+
+// generatorName extracts the tool from the text between "Code generated" and
+// "DO NOT EDIT", such as `by "stringer -type=Pill"; ` or "by MockGen. ".
+func generatorName(text string) string {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, "by ") {
+		return "unknown"
+	}
+	fields := strings.Fields(strings.Trim(strings.TrimPrefix(text, "by "), `"'`))
+	if len(fields) == 0 {
+		return "unknown"
+	}
+	name := strings.TrimRight(fields[0], `.;,"'`)
+	if name == "" {
+		return "unknown"
+	}
+	return name
+}
+
+// generatedSymbol replaces the symbols of a generated file with a single line
+// so the blueprint shows where generated code lives without its bulk.
+func generatedSymbol(generator string, symbols []Symbol) Symbol {
+	exported := 0
+	for _, sym := range symbols {
+		if token.IsExported(sym.Name) {
+			exported++
+		}
+	}
+	return Symbol{
+		Kind:      "generated",
+		Name:      generator,
+		Signature: "// generated by " + generator + ", " + pluralize(exported, "exported symbol") + "; DO NOT EDIT",
+	}
+}
+
+func pluralize(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
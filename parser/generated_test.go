@@ -0,0 +1,23 @@
+package parser
+
+import "testing"
+
+func TestGeneratedBy(t *testing.T) {
+	tests := []struct {
+		src       string
+		generator string
+		generated bool
+	}{
+		{"// Code generated by sqlc. DO NOT EDIT.\n\npackage db\n", "sqlc", true},
+		{"// Copyright 2025 The Authors.\n\n// Code generated by \"stringer -type=Pill\"; DO NOT EDIT.\n\npackage pill\n", "stringer", true},
+		{"/*\nLicense text.\n*/\n\n// Code generated DO NOT EDIT.\n\npackage api\n", "unknown", true},
+		{"package api\n\n// Code generated by hand. DO NOT EDIT.\n", "", false},
+		{"// Code generated by sqlc. Edit freely.\n\npackage db\n", "", false},
+	}
+	for _, tt := range tests {
+		generator, generated := GeneratedBy([]byte(tt.src))
+		if generator != tt.generator || generated != tt.generated {
+			t.Errorf("GeneratedBy(%q) = %q, %t, want %q, %t", tt.src, generator, generated, tt.generator, tt.generated)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package parser
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// maxRenderedCallers caps the callers listed per function in the blueprint.
+const maxRenderedCallers = 8
+
+// Call is a direct call from a function in a file to an exported function,
+// found without type information. Method calls cannot be resolved this way and
+// are not recorded.
+type Call struct {
+	From string // calling function, or Type.Method for methods
+	To   string // import path and name of the callee, or just its name within the package
+}
+
+// collectCalls records the calls from node's functions to exported functions
+// of its own package and of the internal imports.
+func collectCalls(node *ast.File, internal []string) []Call {
+	imported := make(map[string]string)
+	for _, spec := range node.Imports {
+		importPath := strings.Trim(spec.Path.Value, "\"")
+		if !isInternal(importPath, internal) {
+			continue
+		}
+		// The package name is assumed to match the last path element unless
+		// the import is renamed.
+		name := path.Base(importPath)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		imported[name] = importPath
+	}
+
+	var calls []Call
+	seen := make(map[Call]bool)
+	for _, decl := range node.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil {
+			continue
+		}
+		from := fn.Name.Name
+		if fn.Recv != nil && len(fn.Recv.List) > 0 {
+			from = receiverName(fn.Recv.List[0].Type) + "." + from
+		}
+		ast.Inspect(fn.Body, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			to := ""
+			switch fun := call.Fun.(type) {
+			case *ast.Ident:
+				if token.IsExported(fun.Name) {
+					to = fun.Name
+				}
+			case *ast.SelectorExpr:
+				if pkg, ok := fun.X.(*ast.Ident); ok && imported[pkg.Name] != "" && token.IsExported(fun.Sel.Name) {
+					to = imported[pkg.Name] + "." + fun.Sel.Name
+				}
+			}
+			if c := (Call{From: from, To: to}); to != "" && !seen[c] {
+				seen[c] = true
+				calls = append(calls, c)
+			}
+			return true
+		})
+	}
+	return calls
+}
+
+// importGraph maps each package to the internal packages it imports, both
+// sorted and named by their directory relative to the project root, "." for
+// the root itself. Imports of test files are left out, since an external test
+// package may import what its package cannot.
+func (proj *Project) importGraph() map[string][]string {
+	edges := make(map[string]map[string]bool)
+	for _, file := range proj.Files {
+		if isTestFile(file.Path) {
+			continue
+		}
+		from := filepath.Dir(file.Path)
+		for _, imp := range file.Imports {
+			to := proj.importDir(strings.Trim(imp, "\""))
+			if to == "" || to == from {
+				continue
+			}
+			if edges[from] == nil {
+				edges[from] = make(map[string]bool)
+			}
+			edges[from][to] = true
+		}
+	}
+	return sortedGraph(edges)
+}
+
+// callerGraph maps each exported function, named by qualify, to the functions
+// that call it directly. Calls from tests are left out; they would crowd out the callers
+// the model needs to know about.
+func (proj *Project) callerGraph() map[string][]string {
+	exported := make(map[string]bool)
+	for _, file := range proj.Files {
+		for _, sym := range file.Symbols {
+			if sym.Kind == "func" && token.IsExported(sym.Name) {
+				exported[filepath.Dir(file.Path)+"."+sym.Name] = true
+			}
+		}
+	}
+
+	edges := make(map[string]map[string]bool)
+	for _, file := range proj.Files {
+		if isTestFile(file.Path) {
+			continue
+		}
+		dir := filepath.Dir(file.Path)
+		for _, call := range file.Calls {
+			to, name := dir, call.To
+			if i := strings.LastIndex(call.To, "."); i >= 0 {
+				to, name = proj.importDir(call.To[:i]), call.To[i+1:]
+			}
+			if to == "" || !exported[to+"."+name] {
+				continue
+			}
+			callee := qualify(to, name)
+			if edges[callee] == nil {
+				edges[callee] = make(map[string]bool)
+			}
+			edges[callee][qualify(dir, call.From)] = true
+		}
+	}
+	return sortedGraph(edges)
+}
+
+// qualify names a function of the package in dir as "dir.Name", or just
+// "Name" in the project root, as code in that package would refer to it.
+func qualify(dir, name string) string {
+	if dir == "." {
+		return name
+	}
+	return dir + "." + name
+}
+
+func sortedGraph(edges map[string]map[string]bool) map[string][]string {
+	graph := make(map[string][]string, len(edges))
+	for from, targets := range edges {
+		for to := range targets {
+			graph[from] = append(graph[from], to)
+		}
+		sort.Strings(graph[from])
+	}
+	return graph
+}
+
+// renderGraph writes one "key sep values" adjacency line per entry, sorted by
+// key, after the given header line. At most limit values are listed per line
+// when limit is positive.
+func renderGraph(header, sep string, graph map[string][]string, limit int) string {
+	if len(graph) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(graph))
+	for key := range graph {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var builder strings.Builder
+	builder.WriteString(header + "\n")
+	for _, key := range keys {
+		values := graph[key]
+		more := ""
+		if limit > 0 && len(values) > limit {
+			more = fmt.Sprintf(", ... (%d more)", len(values)-limit)
+			values = values[:limit]
+		}
+		builder.WriteString(key + " " + sep + " " + strings.Join(values, ", ") + more + "\n")
+	}
+	builder.WriteString("\n")
+	return builder.String()
+}
+
+// renderImports writes the internal package import graph section.
+func (proj *Project) renderImports() string {
+	return renderGraph("imports (internal package dependencies):", "->", proj.importGraph(), 0)
+}
+
+// renderCallers writes the callers section when it is enabled in the config.
+func (proj *Project) renderCallers() string {
+	if !proj.callers {
+		return ""
+	}
+	return renderGraph("callers (direct calls of exported functions, approximated without types; method calls are not resolved):", "<-", proj.callerGraph(), maxRenderedCallers)
+}
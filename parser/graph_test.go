@@ -0,0 +1,42 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/piqoni/vogte/config"
+)
+
+func TestGraphs(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod":     "module example.com/tool\n\ngo 1.22\n",
+		"main.go":    "package main\n\nimport \"example.com/tool/run\"\n\nfunc main() { run.Start() }\n",
+		"run/run.go": "package run\n\nfunc Start() {}\n",
+		"run/run_test.go": `package run_test
+
+import (
+	"testing"
+
+	"example.com/tool/run"
+)
+
+func TestStart(t *testing.T) { run.Start() }
+`,
+		// A main subpackage must not be confused with the root.
+		"cmd/main/main.go": "package main\n\nimport \"example.com/tool/run\"\n\nfunc main() { run.Start() }\n",
+	})
+
+	project, err := New(&config.Config{}).Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantImports := map[string][]string{".": {"run"}, "cmd/main": {"run"}}
+	if got := project.importGraph(); !reflect.DeepEqual(got, wantImports) {
+		t.Errorf("importGraph = %v, want %v", got, wantImports)
+	}
+	wantCallers := map[string][]string{"run.Start": {"cmd/main.main", "main"}}
+	if got := project.callerGraph(); !reflect.DeepEqual(got, wantCallers) {
+		t.Errorf("callerGraph = %v, want %v", got, wantCallers)
+	}
+}
@@ -0,0 +1,219 @@
+package parser
+
+import (
+	"go/ast"
+	"go/token"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// BM25 parameters: k1 dampens repeated terms and b normalizes for file length.
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// Shortlist limits. Files scoring below shortlistRatio of the best match are
+// left out. A shortlist is confident when the best match covers at least
+// minConfidentTerms terms of the task and at most maxConfidentFiles files
+// remain.
+const (
+	maxShortlist      = 5
+	maxConfidentFiles = 3
+	shortlistRatio    = 0.5
+	minConfidentTerms = 2
+)
+
+// minIndexTermLength matches the shortest term taskTerms keeps.
+const minIndexTermLength = 3
+
+// indexesTerms reports whether files are parsed with the terms of the lexical
+// index. The index ranks files for the file selection step, so the terms are
+// not needed when the "file_selection" setting is "model".
+func (p *Parser) indexesTerms() bool {
+	return p.config.LLM.FileSelection != "model"
+}
+
+// indexTerms counts the terms of a Go file for the lexical index: its
+// identifiers split on camelCase, its comments and its string literals.
+func indexTerms(node *ast.File) map[string]int {
+	terms := make(map[string]int)
+	add := func(text string) {
+		for _, term := range splitIdentifier(text) {
+			if len(term) >= minIndexTermLength && !isNumber(term) {
+				terms[term]++
+			}
+		}
+	}
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch x := n.(type) {
+		case *ast.Ident:
+			add(x.Name)
+		case *ast.BasicLit:
+			if x.Kind == token.STRING {
+				if s, err := strconv.Unquote(x.Value); err == nil {
+					add(s)
+				}
+			}
+		}
+		return true
+	})
+	for _, group := range node.Comments {
+		add(group.Text())
+	}
+	return terms
+}
+
+func isNumber(term string) bool {
+	_, err := strconv.Atoi(term)
+	return err == nil
+}
+
+// Index is a BM25 index over the files of a project, for ranking them against
+// a task without calling the model.
+type Index struct {
+	docs      []indexedFile
+	df        map[string]int // number of files containing each term
+	avgLength float64
+}
+
+type indexedFile struct {
+	path   string
+	terms  map[string]int
+	length int
+}
+
+// Hit is a file matching a search, with its BM25 score.
+type Hit struct {
+	Path  string
+	Score float64
+}
+
+// Shortlist is the result of ranking the files of a project against a task.
+type Shortlist struct {
+	Files []string // best match first
+	// Confident is set when the best match covers every searched term known to
+	// the index and few files come close, so the files can be used as they
+	// are.
+	Confident bool
+}
+
+// NewIndex indexes the files of project. Files kept only as dependencies of a
+// restricted scope are left out. Files parsed without terms, such as .proto
+// files, are indexed by their path and symbols.
+func NewIndex(project *Project) *Index {
+	idx := &Index{df: make(map[string]int)}
+	total := 0
+	for _, file := range project.Files {
+		if file.Dependency {
+			continue
+		}
+		terms := make(map[string]int, len(file.Terms))
+		for term, count := range file.Terms {
+			terms[term] = count
+		}
+		texts := []string{file.Path}
+		if len(file.Terms) == 0 {
+			for _, sym := range file.Symbols {
+				texts = append(texts, sym.Signature, sym.Doc)
+			}
+		}
+		for _, text := range texts {
+			for _, term := range splitIdentifier(text) {
+				if len(term) >= minIndexTermLength {
+					terms[term]++
+				}
+			}
+		}
+
+		length := 0
+		for term, count := range terms {
+			idx.df[term]++
+			length += count
+		}
+		total += length
+		idx.docs = append(idx.docs, indexedFile{path: file.Path, terms: terms, length: length})
+	}
+	if len(idx.docs) > 0 {
+		idx.avgLength = float64(total) / float64(len(idx.docs))
+	}
+	return idx
+}
+
+// Search scores every file against the terms of task and returns the files
+// that match, best first.
+func (idx *Index) Search(task string) []Hit {
+	terms := idx.queryTerms(task)
+	var hits []Hit
+	for _, doc := range idx.docs {
+		score := 0.0
+		for _, term := range terms {
+			tf := float64(doc.terms[term])
+			if tf == 0 {
+				continue
+			}
+			norm := 1 - bm25B + bm25B*float64(doc.length)/idx.avgLength
+			score += idx.idf(term) * tf * (bm25K1 + 1) / (tf + bm25K1*norm)
+		}
+		if score > 0 {
+			hits = append(hits, Hit{Path: doc.path, Score: score})
+		}
+	}
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
+	return hits
+}
+
+// Shortlist returns the files scoring close to the best match for task.
+func (idx *Index) Shortlist(task string) Shortlist {
+	hits := idx.Search(task)
+	if len(hits) == 0 {
+		return Shortlist{}
+	}
+
+	var list Shortlist
+	for _, hit := range hits {
+		if hit.Score < hits[0].Score*shortlistRatio || len(list.Files) == maxShortlist {
+			break
+		}
+		list.Files = append(list.Files, hit.Path)
+	}
+
+	terms := idx.queryTerms(task)
+	best := idx.doc(hits[0].Path)
+	covered := 0
+	for _, term := range terms {
+		if best.terms[term] > 0 {
+			covered++
+		}
+	}
+	list.Confident = covered == len(terms) && covered >= minConfidentTerms && len(list.Files) <= maxConfidentFiles
+	return list
+}
+
+// queryTerms returns the terms of task that occur in at least one file, in a
+// stable order.
+func (idx *Index) queryTerms(task string) []string {
+	var terms []string
+	for term := range taskTerms(task) {
+		if idx.df[term] > 0 {
+			terms = append(terms, term)
+		}
+	}
+	sort.Strings(terms)
+	return terms
+}
+
+func (idx *Index) idf(term string) float64 {
+	n, df := float64(len(idx.docs)), float64(idx.df[term])
+	return math.Log(1 + (n-df+0.5)/(df+0.5))
+}
+
+func (idx *Index) doc(path string) indexedFile {
+	for _, doc := range idx.docs {
+		if doc.path == path {
+			return doc
+		}
+	}
+	return indexedFile{}
+}
@@ -0,0 +1,166 @@
+package parser
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"testing"
+
+	"github.com/piqoni/vogte/config"
+)
+
+// loadTestdata loads a copy of a project under testdata, so the parse cache is
+// not written to the source tree.
+func loadTestdata(t *testing.T, name string) *Project {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.CopyFS(dir, os.DirFS("testdata/"+name)); err != nil {
+		t.Fatal(err)
+	}
+	project, err := New(&config.Config{}).Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return project
+}
+
+func TestSplitIdentifier(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"parseFile", []string{"parse", "file"}},
+		{"HTTPBody", []string{"http", "body"}},
+		{"newHTTPClient", []string{"new", "http", "client"}},
+		{"v2Client", []string{"v2", "client"}},
+		{"snake_case-name", []string{"snake", "case", "name"}},
+		{"Fix the refund, please!", []string{"fix", "the", "refund", "please"}},
+		{"ID", []string{"id"}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := splitIdentifier(tt.in); !slices.Equal(got, tt.want) {
+			t.Errorf("splitIdentifier(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestIndexSearch(t *testing.T) {
+	idx := NewIndex(loadTestdata(t, "index"))
+
+	tests := []struct {
+		task string
+		best string
+	}{
+		{"hash the password with a salt", "auth/password.go"},
+		{"return an error when a refund exceeds the total", "billing/refund.go"},
+		{"the session token expires too early", "auth/session.go"},
+		{"add a route for logout next to the login handler", "server/routes.go"},
+		{"include the tax rate on each invoice line", "billing/invoice.go"},
+		// Test files are indexed by their terms, not the one-line summary.
+		{"the round trip test", "auth/password_test.go"},
+	}
+	for _, tt := range tests {
+		hits := idx.Search(tt.task)
+		if len(hits) == 0 || hits[0].Path != tt.best {
+			t.Errorf("Search(%q) = %v, want %s first", tt.task, hits, tt.best)
+		}
+		for i := 1; i < len(hits); i++ {
+			if hits[i].Score > hits[i-1].Score {
+				t.Errorf("Search(%q) is not sorted by score: %v", tt.task, hits)
+			}
+		}
+	}
+
+	if hits := idx.Search("xyzzy plugh"); len(hits) != 0 {
+		t.Errorf("Search of unknown terms = %v, want no hits", hits)
+	}
+}
+
+func TestShortlistConfidence(t *testing.T) {
+	idx := NewIndex(loadTestdata(t, "index"))
+
+	tests := []struct {
+		task      string
+		first     string
+		confident bool
+	}{
+		// The best match covers both terms and stands out.
+		{"hash password salt", "auth/password.go", true},
+		// A single known term is too little to go on.
+		{"password", "auth/password.go", false},
+		// No file covers both terms.
+		{"password refund", "", false},
+		// Unknown terms leave nothing to rank.
+		{"xyzzy", "", false},
+	}
+	for _, tt := range tests {
+		list := idx.Shortlist(tt.task)
+		if list.Confident != tt.confident {
+			t.Errorf("Shortlist(%q).Confident = %t, want %t (files %v)", tt.task, list.Confident, tt.confident, list.Files)
+		}
+		if tt.first != "" && (len(list.Files) == 0 || list.Files[0] != tt.first) {
+			t.Errorf("Shortlist(%q).Files = %v, want %s first", tt.task, list.Files, tt.first)
+		}
+		if len(list.Files) > maxShortlist {
+			t.Errorf("Shortlist(%q) has %d files, want at most %d", tt.task, len(list.Files), maxShortlist)
+		}
+	}
+}
+
+func TestShortlistLimits(t *testing.T) {
+	// Files matching equally well: too many to be confident, and the list is
+	// capped at maxShortlist.
+	project := &Project{}
+	for i := range maxShortlist + 2 {
+		project.Files = append(project.Files, &File{
+			Path:  fmt.Sprintf("pkg/file%d.go", i),
+			Terms: map[string]int{"cache": 2, "entry": 2, fmt.Sprintf("filler%c", 'a'+i): 3},
+		})
+	}
+	list := NewIndex(project).Shortlist("cache entry")
+	if len(list.Files) != maxShortlist || list.Confident {
+		t.Errorf("Shortlist = %v (confident %t), want %d files, not confident", list.Files, list.Confident, maxShortlist)
+	}
+
+	// Files scoring below shortlistRatio of the best are left out.
+	project.Files[0].Terms["cache"] = 40
+	project.Files[0].Terms["entry"] = 40
+	for _, file := range project.Files[1:] {
+		file.Terms = map[string]int{"cache": 1, "unrelated": 30}
+	}
+	list = NewIndex(project).Shortlist("cache entry")
+	if !slices.Equal(list.Files, []string{"pkg/file0.go"}) || !list.Confident {
+		t.Errorf("Shortlist = %v (confident %t), want only pkg/file0.go, confident", list.Files, list.Confident)
+	}
+}
+
+func TestTermsOnlyWhenIndexed(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.CopyFS(dir, os.DirFS("testdata/index")); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{}
+	cfg.LLM.FileSelection = "model"
+	project, err := New(cfg).Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range project.Files {
+		if file.Terms != nil {
+			t.Errorf("%s has terms with file selection by the model", file.Path)
+		}
+	}
+
+	// The cache built without terms is not served once they are needed.
+	project, err = New(&config.Config{}).Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range project.Files {
+		if path.Ext(file.Path) == ".go" && len(file.Terms) == 0 {
+			t.Errorf("%s has no terms with the default file selection", file.Path)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+)
+
+// Module is a Go module that files of the project belong to.
+type Module struct {
+	Path      string // module path from go.mod
+	Dir       string // directory relative to the project root
+	Workspace bool   // listed in a use directive of the go.work file
+	// The go.mod directives that decide which language features and
+	// dependency APIs are available.
+	Go        string   // go version, such as "1.22"
+	Toolchain string   // toolchain directive, if any
+	Require   []string // direct requirements, as "path version"
+	Replace   []string // replace directives, as "old => new"
+
+	abs string
+}
+
+// Workspace describes the go.work file governing the project, if any.
+type Workspace struct {
+	File string   `json:"file"` // path relative to the project root
+	Use  []string `json:"use"`  // use directives as written in go.work
+}
+
+// resolveModules builds the module list from the go.mod files found by the
+// walk. If the project root itself is not a module, the nearest go.mod above
+// it is used. Modules named in go.work use directives are added even when
+// they live outside the project root.
+func (p *Parser) resolveModules(dir string, goMods []string) ([]*Module, *Workspace) {
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		root = dir
+	}
+
+	var modules []*Module
+	byDir := make(map[string]*Module)
+	add := func(goMod string) *Module {
+		abs := filepath.Dir(goMod)
+		if absPath, err := filepath.Abs(abs); err == nil {
+			abs = absPath
+		}
+		if m, ok := byDir[abs]; ok {
+			return m
+		}
+		m := p.parseGoMod(goMod)
+		if m == nil {
+			return nil
+		}
+		rel, err := relativePath(root, abs)
+		if err != nil {
+			rel = filepath.ToSlash(abs)
+		}
+		m.Dir, m.abs = rel, abs
+		byDir[abs] = m
+		modules = append(modules, m)
+		return m
+	}
+
+	for _, goMod := range goMods {
+		add(goMod)
+	}
+	if _, ok := byDir[root]; !ok {
+		if goMod := findUpward(root, "go.mod"); goMod != "" {
+			add(goMod)
+		}
+	}
+
+	var workspace *Workspace
+	if goWork := findUpward(root, "go.work"); goWork != "" {
+		if data, err := os.ReadFile(goWork); err == nil {
+			if work, err := modfile.ParseWork(goWork, data, nil); err == nil {
+				rel, _ := relativePath(root, goWork)
+				workspace = &Workspace{File: rel}
+				for _, use := range work.Use {
+					workspace.Use = append(workspace.Use, use.Path)
+					useDir := use.Path
+					if !filepath.IsAbs(useDir) {
+						useDir = filepath.Join(filepath.Dir(goWork), useDir)
+					}
+					if m := add(filepath.Join(useDir, "go.mod")); m != nil {
+						m.Workspace = true
+					}
+				}
+			}
+		}
+	}
+
+	sort.Slice(modules, func(i, j int) bool { return modules[i].Dir < modules[j].Dir })
+	return modules, workspace
+}
+
+// findUpward looks for name in dir and its parents and returns its path, or an
+// empty string if it is not found.
+func findUpward(dir, name string) string {
+	for {
+		candidate := filepath.Join(dir, name)
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// moduleFor returns the nearest module enclosing the file at path, or nil if
+// the file is not part of any module.
+func moduleFor(modules []*Module, path string) *Module {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil
+	}
+	var best *Module
+	for _, m := range modules {
+		if abs != m.abs && !strings.HasPrefix(abs, m.abs+string(filepath.Separator)) {
+			continue
+		}
+		if best == nil || len(m.abs) > len(best.abs) {
+			best = m
+		}
+	}
+	return best
+}
+
+// internalPaths returns the module paths whose imports count as internal for
+// files of m: m itself and, when m is part of the workspace, every module
+// listed in go.work.
+func internalPaths(modules []*Module, m *Module) []string {
+	if m == nil {
+		return nil
+	}
+	paths := []string{m.Path}
+	if m.Workspace {
+		for _, other := range modules {
+			if other.Workspace && other != m {
+				paths = append(paths, other.Path)
+			}
+		}
+	}
+	return paths
+}
+
+// isInternal reports whether the import path belongs to one of the modules.
+func isInternal(importPath string, modules []string) bool {
+	for _, m := range modules {
+		if importPath == m || strings.HasPrefix(importPath, m+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// importDir maps an internal import path to its directory relative to the
+// project root, or returns an empty string for imports outside the modules.
+func (proj *Project) importDir(importPath string) string {
+	var best *Module
+	for _, m := range proj.Modules {
+		if isInternal(importPath, []string{m.Path}) && (best == nil || len(m.Path) > len(best.Path)) {
+			best = m
+		}
+	}
+	if best == nil {
+		return ""
+	}
+	rest := strings.TrimPrefix(strings.TrimPrefix(importPath, best.Path), "/")
+	rel, err := relativePath(proj.Dir, filepath.Join(best.abs, rest))
+	if err != nil {
+		return ""
+	}
+	return rel
+}
+
+// parseGoMod reads the module path and the header directives of a go.mod
+// file. It returns nil if the file cannot be read or has no module directive.
+func (p *Parser) parseGoMod(filePath string) *Module {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil
+	}
+
+	modFile, err := modfile.Parse(filePath, data, nil)
+	if err != nil || modFile.Module == nil {
+		return nil
+	}
+
+	m := &Module{Path: modFile.Module.Mod.Path}
+	if modFile.Go != nil {
+		m.Go = modFile.Go.Version
+	}
+	if modFile.Toolchain != nil {
+		m.Toolchain = modFile.Toolchain.Name
+	}
+	for _, req := range modFile.Require {
+		if !req.Indirect {
+			m.Require = append(m.Require, req.Mod.Path+" "+req.Mod.Version)
+		}
+	}
+	for _, rep := range modFile.Replace {
+		m.Replace = append(m.Replace, moduleVersion(rep.Old)+" => "+moduleVersion(rep.New))
+	}
+	return m
+}
+
+func moduleVersion(v module.Version) string {
+	if v.Version == "" {
+		return v.Path
+	}
+	return v.Path + " " + v.Version
+}
+
+// goModLines renders the go.mod directives of m as they would be written in
+// go.mod, one per line.
+func (m *Module) goModLines() []string {
+	var lines []string
+	if m.Go != "" {
+		lines = append(lines, "go "+m.Go)
+	}
+	if m.Toolchain != "" {
+		lines = append(lines, "toolchain "+m.Toolchain)
+	}
+	for _, req := range m.Require {
+		lines = append(lines, "require "+req)
+	}
+	for _, rep := range m.Replace {
+		lines = append(lines, "replace "+rep)
+	}
+	return lines
+}
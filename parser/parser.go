@@ -8,98 +8,431 @@ import (
 	"go/token"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"sync"
 
-	"github.com/emicklei/proto"
-	"golang.org/x/mod/modfile"
+	"github.com/piqoni/vogte/config"
 )
 
-type Parser struct{}
+type Parser struct {
+	config *config.Config
+}
+
+// Project is the parsed summary of a repository, one entry per source file in
+// walk order.
+type Project struct {
+	Dir       string
+	Modules   []*Module
+	Workspace *Workspace // nil unless a go.work file applies
+	Files     []*File
+	Scope     []string // package patterns the files are restricted to, if any
+	Types     string   // type-checked section, empty unless enabled in config
+	// Dependencies lists the third-party APIs the project references, empty
+	// unless enabled in config.
+	Dependencies string
+	Diagnostics  []Diagnostic
 
-func New() *Parser {
-	return &Parser{}
+	typeEntries []typeEntry // the types section per named type
+
+	callers bool // render the caller graph section
+	apiOnly bool // render the exported symbol count of each package
 }
 
-func (p *Parser) ParseProject(dir string) (string, error) {
-	var result strings.Builder
-	modulePath := ""
-	if err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+// File is the summary of a single Go or proto source file.
+type File struct {
+	Path      string // relative to the project root
+	Module    string // path of the nearest enclosing module
+	Package   string
+	Generator string   // tool named in the "Code generated" header, if any
+	Imports   []string // internal imports, quoted as in the source
+	// ProtoImports lists the files a proto file imports, quoted. They are
+	// kept apart from Imports, which the import graph resolves to packages.
+	ProtoImports []string
+	Symbols      []Symbol
+	Calls        []Call
+	// Uses lists the exported identifiers referenced from each third-party
+	// import, and Selectors the exported names selected on values, which
+	// pick the methods shown for third-party types.
+	Uses      map[string][]string
+	Selectors []string
+	// Terms counts the words of the file's identifiers, comments and string
+	// literals for the lexical index. It is nil unless the index is used,
+	// see Parser.indexesTerms.
+	Terms map[string]int
+	// Dependency marks a file kept only because a scoped package imports it;
+	// its symbols are reduced to exported signatures.
+	Dependency bool
+	// Notes mention the variants of the file excluded by build constraints.
+	Notes []string
+
+	excluded string // variant label of a file excluded by build constraints
+}
+
+// Symbol is a single declaration as it appears in the blueprint.
+type Symbol struct {
+	Kind      string `json:"kind"` // func, method, type, const, var, message, enum, service, option, tests or generated
+	Name      string `json:"name"`
+	Receiver  string `json:"receiver,omitempty"` // receiver type name for methods
+	Signature string `json:"signature"`
+	Doc       string `json:"doc,omitempty"` // doc comment text in the configured mode
+	// Constructs is the type a function returns, for listing constructors
+	// under their type as go/doc does.
+	Constructs string `json:"constructs,omitempty"`
+	Line       int    `json:"line,omitempty"` // line of the declaration, zero when unknown
+}
+
+func New(cfg *config.Config) *Parser {
+	return &Parser{
+		config: cfg,
+	}
+}
+
+// ParseProject returns the blueprint of the project in dir, compacted to the
+// configured token budget if one is set, along with diagnostics for the files
+// that could not be parsed.
+func (p *Parser) ParseProject(dir string) (string, []Diagnostic, error) {
+	return p.ParseProjectAs(dir, FormatText)
+}
+
+// ParseProjectAs is like ParseProject but writes the blueprint in the given
+// format.
+func (p *Parser) ParseProjectAs(dir string, format Format) (string, []Diagnostic, error) {
+	project, err := p.LoadPackages(dir, p.config.Context.Packages)
+	if err != nil {
+		return "", nil, err
+	}
+	output, err := p.Render(project, format, "", p.config.Context.MaxTokens)
+	if err != nil {
+		return "", nil, err
+	}
+	return output, project.Diagnostics, nil
+}
+
+// parseJob is a file found by the walk, along with its enclosing module and the
+// module paths whose imports count as internal for it.
+type parseJob struct {
+	path     string
+	rel      string
+	info     os.FileInfo
+	module   *Module
+	internal []string
+}
+
+// Load walks dir and summarizes every Go and proto file that is not excluded
+// by .gitignore or the configured globs. Files that cannot be read or parsed
+// are reported in the project's diagnostics instead of failing the load; an
+// error is only returned when dir itself cannot be walked.
+func (p *Parser) Load(dir string) (*Project, error) {
+	project, err := p.load(dir)
+	if err != nil {
+		return nil, err
+	}
+	if p.config.Context.Dependencies {
+		project.Dependencies = p.parseDependencies(project)
+	}
+	return project, nil
+}
+
+// load is Load without the dependencies section, which depends on the
+// packages the project is restricted to.
+func (p *Parser) load(dir string) (*Project, error) {
+	project, err := p.loadFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.config.Context.TypeCheck {
+		entries, diagnostics, err := p.parseTypes(dir, project)
 		if err != nil {
-			return err
+			diagnostics = append(diagnostics, Diagnostic{File: ".", Message: typeCheckFailed + err.Error(), Severity: SeverityWarning})
 		}
+		project.typeEntries = entries
+		project.Types = renderTypes(entries)
+		project.Diagnostics = append(project.Diagnostics, diagnostics...)
+	}
+
+	return project, nil
+}
 
-		if !info.IsDir() && strings.HasSuffix(path, ".proto") {
-			fileContent, err := p.parseProtoFile(path)
+// loadFiles summarizes the files of the project in dir, without the sections
+// that need more than the files themselves.
+func (p *Parser) loadFiles(dir string) (*Project, error) {
+	project := &Project{Dir: dir, callers: p.config.Context.Callers, apiOnly: p.apiOnly()}
+	cache := p.loadCache(dir)
+	var goMods []string
+	var jobs []parseJob
+	diagnostics, err := p.walk(dir, func(path string, info os.FileInfo) error {
+		if filepath.Base(path) == "go.mod" {
+			goMods = append(goMods, path)
+		}
+
+		// Tests are not part of the exported API either.
+		if isTestFile(path) && (p.config.Context.SkipTests || p.apiOnly()) {
+			return nil
+		}
+		if strings.HasSuffix(path, ".proto") || strings.HasSuffix(path, ".go") {
+			rel, err := relativePath(dir, path)
 			if err != nil {
-				return fmt.Errorf("error parsing file %s: %w", path, err)
+				return err
 			}
-			relativePath := strings.TrimPrefix(path, dir)
-			relativePath = strings.TrimPrefix(relativePath, "/")
-			result.WriteString("file: " + relativePath + "\n")
-			result.WriteString(fileContent + "\n")
+			jobs = append(jobs, parseJob{path: path, rel: rel, info: info})
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error walking the directory: %w", err)
+	}
+	project.Diagnostics = diagnostics
+
+	project.Modules, project.Workspace = p.resolveModules(dir, goMods)
+	for i := range jobs {
+		jobs[i].module = moduleFor(project.Modules, jobs[i].path)
+		jobs[i].internal = internalPaths(project.Modules, jobs[i].module)
+	}
+
+	files, diagnostics := p.parseAll(jobs, cache)
+	cache.save()
+	for _, file := range p.noteVariants(files) {
+		if file.Generator != "" && p.config.Context.SkipGenerated {
+			continue
 		}
+		project.Files = append(project.Files, file)
+	}
+	if p.apiOnly() {
+		groupPackages(project.Files)
+	}
+	project.Files = summarizeTests(project.Files)
+	project.Diagnostics = append(project.Diagnostics, diagnostics...)
+	return project, nil
+}
+
+// String renders the whole project as a blueprint.
+func (proj *Project) String() string {
+	return proj.render(textRenderer{})
+}
 
-		if !info.IsDir() && strings.HasSuffix(path, "go.mod") {
-			modulePath = p.getModulePath(path)
+// render writes the whole project in the format of r.
+func (proj *Project) render(r renderer) string {
+	return r.begin() +
+		proj.renderFiles(r, func(file *File) string { return r.file(file, file.Symbols) }) +
+		r.section("api", proj.renderAPI()) +
+		r.section("imports", proj.renderImports()) +
+		r.section("callers", proj.renderCallers()) +
+		r.section("types", proj.Types) +
+		r.section("dependencies", proj.Dependencies) +
+		r.section("diagnostics", renderDiagnostics(proj.Diagnostics, maxRenderedDiagnostics)) +
+		r.end()
+}
+
+// renderFiles renders the files grouped by module, opening each module's
+// section with a header. Files outside any module come first. render may
+// return an empty string to leave a file out.
+func (proj *Project) renderFiles(r renderer, render func(*File) string) string {
+	var result strings.Builder
+	if proj.Workspace != nil {
+		result.WriteString(r.workspace(proj.Workspace))
+	}
+	if len(proj.Scope) > 0 {
+		result.WriteString(r.scope(proj.Scope))
+	}
+	for _, file := range proj.Files {
+		if file.Module == "" {
+			result.WriteString(render(file))
+		}
+	}
+	for _, m := range proj.Modules {
+		var section strings.Builder
+		for _, file := range proj.Files {
+			if file.Module == m.Path {
+				section.WriteString(render(file))
+			}
+		}
+		if section.Len() > 0 {
+			result.WriteString(r.module(m, section.String()))
 		}
+	}
+	return result.String()
+}
 
-		// Parse Go files
-		if !info.IsDir() && strings.HasSuffix(path, ".go") {
-			// Skip generated protobuf/grpc gateway files
-			if strings.HasSuffix(path, ".pb.go") || strings.HasSuffix(path, ".pb.gw.go") {
-				return nil
+// String renders the file entry as it appears in the blueprint.
+func (f *File) String() string {
+	return textRenderer{}.file(f, f.Symbols)
+}
+
+// parseAll parses the jobs on a pool of GOMAXPROCS workers. Results keep the
+// walk order, so the output is identical to parsing the files one by one.
+// Files that fail to parse are left out and reported as diagnostics.
+func (p *Parser) parseAll(jobs []parseJob, cache *parseCache) ([]*File, []Diagnostic) {
+	files := make([]*File, len(jobs))
+	errs := make([]error, len(jobs))
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range min(runtime.GOMAXPROCS(0), len(jobs)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				files[i], errs[i] = p.parseJob(jobs[i], cache)
 			}
+		}()
+	}
+	for i := range jobs {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
 
-			fileContent, err := p.parseGoFile(path, modulePath)
-			if err != nil {
-				return fmt.Errorf("error parsing file %s: %w", path, err)
+	var parsed []*File
+	var diagnostics []Diagnostic
+	for i, err := range errs {
+		if err != nil {
+			diagnostics = append(diagnostics, diagnosticsFromError(jobs[i].rel, err)...)
+			continue
+		}
+		parsed = append(parsed, files[i])
+	}
+	return parsed, diagnostics
+}
+
+func (p *Parser) parseJob(job parseJob, cache *parseCache) (*File, error) {
+	if strings.HasSuffix(job.path, ".go") {
+		dir, name := filepath.Split(job.path)
+		if match, err := p.buildContext().MatchFile(dir, name); err == nil && !match {
+			file := p.excludedFile(job.path)
+			file.Path = job.rel
+			if job.module != nil {
+				file.Module = job.module.Path
 			}
-			relativePath := strings.TrimPrefix(path, dir)
-			relativePath = strings.TrimPrefix(relativePath, "/")
-			result.WriteString("file: " + relativePath + "\n")
-			result.WriteString(fileContent + "\n")
+			return file, nil
 		}
+	}
 
-		return nil
-	}); err != nil {
-		fmt.Printf("Error walking the directory: %v\n", err)
-		os.Exit(1)
+	file, err := cache.get(job.rel, job.path, job.info, strings.Join(job.internal, ","), func() (*File, error) {
+		if strings.HasSuffix(job.path, ".proto") {
+			return p.parseProtoFile(job.path)
+		}
+		return p.parseGoFile(job.path, job.internal)
+	})
+	if err != nil {
+		return nil, err
+	}
+	file.Path = job.rel
+	if job.module != nil {
+		file.Module = job.module.Path
 	}
+	return file, nil
+}
 
-	return result.String(), nil
+// relativePath returns path relative to the project root dir, with forward
+// slashes. Both are made absolute first, so it does not matter whether dir was
+// given as "./module", "module" or an absolute path.
+func relativePath(dir, path string) (string, error) {
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(rel), nil
 }
 
-func (p *Parser) parseGoFile(filePath, modulePath string) (string, error) {
+func (p *Parser) parseGoFile(filePath string, internal []string) (*File, error) {
+	src, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
 	fset := token.NewFileSet()
-	node, err := parser.ParseFile(fset, filePath, nil, parser.AllErrors)
+	node, err := parser.ParseFile(fset, filePath, src, parser.AllErrors|parser.ParseComments)
 
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	var builder strings.Builder
-	builder.WriteString("package " + node.Name.Name + "\n")
+	file := &File{Package: node.Name.Name, Calls: collectCalls(node, internal)}
+	file.Uses, file.Selectors = collectUses(node, internal)
+	if p.indexesTerms() {
+		file.Terms = indexTerms(node)
+	}
+	// Constants, variables and named non-struct types are only summarized at
+	// package level; local declarations are implementation detail.
+	topLevel := make(map[*ast.GenDecl]bool)
+	for _, decl := range node.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok {
+			topLevel[gen] = true
+		}
+	}
 	ast.Inspect(node, func(n ast.Node) bool {
 		switch x := n.(type) {
 		case *ast.ImportSpec:
 			// Collect only internal imports for now
-			if modulePath != "" && strings.Contains(x.Path.Value, modulePath) {
-				builder.WriteString("import " + x.Path.Value + "\n")
+			if isInternal(strings.Trim(x.Path.Value, "\""), internal) {
+				file.Imports = append(file.Imports, x.Path.Value)
 			}
 		case *ast.FuncDecl:
-			builder.WriteString(p.formatFunctionSignature(fset, x) + "\n")
+			sym := Symbol{
+				Kind:       "func",
+				Name:       x.Name.Name,
+				Signature:  p.formatFunctionSignature(fset, x),
+				Doc:        p.docText(x.Doc),
+				Constructs: constructedType(x),
+				Line:       fset.Position(x.Pos()).Line,
+			}
+			if x.Recv != nil && len(x.Recv.List) > 0 {
+				sym.Kind = "method"
+				sym.Receiver = receiverName(x.Recv.List[0].Type)
+			}
+			file.Symbols = append(file.Symbols, sym)
 		case *ast.GenDecl:
-			if x.Tok == token.TYPE {
+			if p.apiOnly() && !topLevel[x] {
+				break
+			}
+			switch x.Tok {
+			case token.TYPE:
 				for _, spec := range x.Specs {
 					typeSpec, ok := spec.(*ast.TypeSpec)
 					if !ok {
 						continue
 					}
 					switch typeSpec.Type.(type) {
-					case *ast.StructType:
-						builder.WriteString(p.formatNode(fset, typeSpec) + "\n")
-					case *ast.InterfaceType:
-						builder.WriteString(p.formatNode(fset, typeSpec) + "\n")
+					case *ast.StructType, *ast.InterfaceType:
+						if typeSpec.Assign.IsValid() {
+							file.Symbols = append(file.Symbols, p.formatTypeSpec(fset, x, typeSpec))
+							continue
+						}
+						file.Symbols = append(file.Symbols, Symbol{
+							Kind:      "type",
+							Name:      typeSpec.Name.Name,
+							Signature: p.formatTypeWithDocs(fset, typeSpec),
+							Doc:       p.docText(typeDoc(x, typeSpec)),
+							Line:      fset.Position(typeSpec.Pos()).Line,
+						})
+					default:
+						if topLevel[x] {
+							file.Symbols = append(file.Symbols, p.formatTypeSpec(fset, x, typeSpec))
+						}
+					}
+				}
+			case token.CONST:
+				if sym, ok := p.formatConstDecl(fset, x); ok && topLevel[x] {
+					file.Symbols = append(file.Symbols, sym)
+				}
+			case token.VAR:
+				if !topLevel[x] {
+					break
+				}
+				for _, spec := range x.Specs {
+					if valueSpec, ok := spec.(*ast.ValueSpec); ok {
+						file.Symbols = append(file.Symbols, p.formatVarSpec(fset, valueSpec)...)
 					}
 				}
 			}
@@ -107,43 +440,40 @@ func (p *Parser) parseGoFile(filePath, modulePath string) (string, error) {
 		return true
 	})
 
-	return builder.String(), nil
-}
-
-func (p *Parser) getModulePath(filePath string) string {
-	data, err := os.ReadFile(filePath)
-	if err != nil {
-		return ""
+	if p.apiOnly() {
+		var exported []Symbol
+		for _, sym := range file.Symbols {
+			if sym.exported() {
+				exported = append(exported, sym)
+			}
+		}
+		file.Symbols = groupByType(exported)
 	}
 
-	modFile, err := modfile.Parse(filePath, data, nil)
-	if err != nil {
-		return ""
+	// Generated files are collapsed to a single line; the model should change
+	// their source instead of editing them.
+	if generator, ok := GeneratedBy(src); ok {
+		file.Generator = generator
+		file.Symbols = []Symbol{generatedSymbol(generator, file.Symbols)}
 	}
 
-	return modFile.Module.Mod.Path
+	return file, nil
 }
 
-// Extract from proto files messages and services names
-func (p *Parser) parseProtoFile(filepath string) (string, error) {
-	reader, _ := os.Open(filepath)
-	defer reader.Close()
-
-	parser := proto.NewParser(reader)
-	definition, _ := parser.Parse()
-
-	var content []string
-	proto.Walk(definition,
-		proto.WithMessage(func(m *proto.Message) {
-			content = append(content, "message "+m.Name)
-		}),
-		proto.WithService(func(s *proto.Service) {
-			content = append(content, "service "+s.Name)
-		}),
-	)
-
-	result := strings.Join(content, "\n")
-	return result, nil
+// receiverName returns the bare type name of a method receiver, stripping
+// pointers and type parameters.
+func receiverName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return receiverName(t.X)
+	case *ast.IndexExpr:
+		return receiverName(t.X)
+	case *ast.IndexListExpr:
+		return receiverName(t.X)
+	case *ast.Ident:
+		return t.Name
+	}
+	return ""
 }
 
 func (p *Parser) formatFunctionSignature(fset *token.FileSet, funcDecl *ast.FuncDecl) string {
@@ -173,8 +503,10 @@ func (p *Parser) formatFunctionSignature(fset *token.FileSet, funcDecl *ast.Func
 	return builder.String()
 }
 
+// formatNode prints node without field comments; formatTypeWithDocs adds back
+// the ones the docs mode keeps.
 func (p *Parser) formatNode(fset *token.FileSet, node ast.Node) string {
 	var buf strings.Builder
-	printer.Fprint(&buf, fset, node)
+	printer.Fprint(&buf, fset, withoutComments(node))
 	return buf.String()
 }
@@ -0,0 +1,142 @@
+package parser
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/piqoni/vogte/config"
+)
+
+// writeTree writes a synthetic module of packages with files each to dir. Every
+// package imports the one before it, so the imports section is populated too.
+func writeTree(tb testing.TB, dir string, packages, files int) {
+	tb.Helper()
+	tree := map[string]string{"go.mod": "module example.com/synthetic\n\ngo 1.22\n"}
+	for p := range packages {
+		for f := range files {
+			imports := `import "fmt"`
+			use := "fmt.Sprint(n)"
+			if p > 0 {
+				imports = fmt.Sprintf("import (\n\t\"fmt\"\n\n\t\"example.com/synthetic/pkg%03d\"\n)", p-1)
+				use = fmt.Sprintf("fmt.Sprint(pkg%03d.NewType%03d(n))", p-1, f)
+			}
+			tree[fmt.Sprintf("pkg%03d/file%03d.go", p, f)] = fmt.Sprintf(`// Package pkg%03[1]d is generated for the parser benchmark.
+package pkg%03[1]d
+
+%[3]s
+
+// Type%03[2]d holds a counter.
+type Type%03[2]d struct {
+	// N is the current value.
+	N    int
+	Name string // display name
+}
+
+// NewType%03[2]d returns a Type%03[2]d starting at n.
+func NewType%03[2]d(n int) *Type%03[2]d {
+	return &Type%03[2]d{N: n}
+}
+
+// Increment adds one to the counter. It returns the new value.
+func (t *Type%03[2]d) Increment() int {
+	t.N++
+	return t.N
+}
+
+// Describe formats n.
+func Describe%03[2]d(n int) string {
+	return %[4]s
+}
+
+const limit%03[2]d = 10
+
+var defaultName%03[2]d = "type%03[2]d"
+`, p, f, imports, use)
+		}
+	}
+	writeFiles(tb, dir, tree)
+}
+
+// writeFiles writes files, keyed by their slash-separated path relative to dir,
+// creating the directories they need.
+func writeFiles(tb testing.TB, dir string, files map[string]string) {
+	tb.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			tb.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			tb.Fatal(err)
+		}
+	}
+}
+
+// parseFresh parses dir without a cache, so every file is parsed.
+func parseFresh(tb testing.TB, p *Parser, dir string) string {
+	tb.Helper()
+	if err := os.RemoveAll(filepath.Join(dir, ".vogte")); err != nil {
+		tb.Fatal(err)
+	}
+	output, _, err := p.ParseProject(dir)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return output
+}
+
+func TestParseProjectIsDeterministic(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, 20, 10)
+	p := New(&config.Config{})
+
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(0))
+	runtime.GOMAXPROCS(1)
+	want := parseFresh(t, p, dir)
+
+	for _, procs := range []int{1, 2, 4, 16} {
+		runtime.GOMAXPROCS(procs)
+		for run := range 3 {
+			if got := parseFresh(t, p, dir); got != want {
+				t.Fatalf("GOMAXPROCS=%d run %d: output differs from the sequential parse", procs, run)
+			}
+		}
+	}
+
+	// A warm cache serves the same output.
+	if got, _, err := p.ParseProject(dir); err != nil || got != want {
+		t.Fatalf("cached parse differs from the fresh parse (err %v)", err)
+	}
+}
+
+// BenchmarkParseProject parses a synthetic tree of 3000 files with one worker
+// and with one per CPU, showing the speedup of the worker pool.
+func BenchmarkParseProject(b *testing.B) {
+	dir := b.TempDir()
+	writeTree(b, dir, 100, 30)
+	p := New(&config.Config{})
+
+	procs := []int{1}
+	if n := runtime.NumCPU(); n > 1 {
+		procs = append(procs, n)
+	}
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(0))
+	for _, procs := range procs {
+		b.Run(fmt.Sprintf("procs=%d", procs), func(b *testing.B) {
+			runtime.GOMAXPROCS(procs)
+			for b.Loop() {
+				b.StopTimer()
+				if err := os.RemoveAll(filepath.Join(dir, ".vogte")); err != nil {
+					b.Fatal(err)
+				}
+				b.StartTimer()
+				if _, _, err := p.ParseProject(dir); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
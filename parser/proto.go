@@ -0,0 +1,174 @@
+package parser
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/emicklei/proto"
+)
+
+// parseProtoFile summarizes a proto file: its package, imports and options, every
+// message with its fields (nested messages qualified as Outer.Inner), enums
+// with their values and services with full rpc signatures.
+func (p *Parser) parseProtoFile(filePath string) (*File, error) {
+	reader, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	definition, err := proto.NewParser(reader).Parse()
+	if err != nil {
+		return nil, err
+	}
+
+	file := &File{}
+	for _, element := range definition.Elements {
+		switch x := element.(type) {
+		case *proto.Package:
+			file.Package = x.Name
+		case *proto.Import:
+			file.ProtoImports = append(file.ProtoImports, strconv.Quote(x.Filename))
+		case *proto.Option:
+			file.Symbols = append(file.Symbols, Symbol{
+				Kind:      "option",
+				Name:      x.Name,
+				Signature: "option " + protoOption(x),
+				Line:      x.Position.Line,
+			})
+		case *proto.Message:
+			file.Symbols = append(file.Symbols, protoMessage(x, "")...)
+		case *proto.Enum:
+			file.Symbols = append(file.Symbols, protoEnum(x, ""))
+		case *proto.Service:
+			file.Symbols = append(file.Symbols, protoService(x))
+		}
+	}
+
+	return file, nil
+}
+
+// protoMessage renders a message on one line followed by its nested messages
+// and enums, which are qualified with the parent name.
+func protoMessage(m *proto.Message, parent string) []Symbol {
+	name := m.Name
+	if parent != "" {
+		name = parent + "." + m.Name
+	}
+	keyword := "message"
+	if m.IsExtend {
+		keyword = "extend"
+	}
+
+	var fields []string
+	var nested []Symbol
+	for _, element := range m.Elements {
+		switch x := element.(type) {
+		case *proto.NormalField:
+			label := ""
+			switch {
+			case x.Repeated:
+				label = "repeated "
+			case x.Optional:
+				label = "optional "
+			case x.Required:
+				label = "required "
+			}
+			fields = append(fields, label+protoField(x.Type, x.Field))
+		case *proto.MapField:
+			fields = append(fields, protoField(fmt.Sprintf("map<%s, %s>", x.KeyType, x.Type), x.Field))
+		case *proto.Oneof:
+			var choices []string
+			for _, choice := range x.Elements {
+				if f, ok := choice.(*proto.OneOfField); ok {
+					choices = append(choices, protoField(f.Type, f.Field))
+				}
+			}
+			fields = append(fields, "oneof "+x.Name+" { "+strings.Join(choices, "; ")+" }")
+		case *proto.Message:
+			nested = append(nested, protoMessage(x, name)...)
+		case *proto.Enum:
+			nested = append(nested, protoEnum(x, name))
+		}
+	}
+
+	symbols := []Symbol{{
+		Kind:      "message",
+		Name:      name,
+		Signature: keyword + " " + name + " { " + strings.Join(fields, "; ") + " }",
+		Line:      m.Position.Line,
+	}}
+	return append(symbols, nested...)
+}
+
+// protoField renders "type name = number [options]".
+func protoField(typ string, f *proto.Field) string {
+	field := fmt.Sprintf("%s %s = %d", typ, f.Name, f.Sequence)
+	if len(f.Options) > 0 {
+		var options []string
+		for _, option := range f.Options {
+			options = append(options, protoOption(option))
+		}
+		field += " [" + strings.Join(options, ", ") + "]"
+	}
+	return field
+}
+
+func protoEnum(e *proto.Enum, parent string) Symbol {
+	name := e.Name
+	if parent != "" {
+		name = parent + "." + e.Name
+	}
+
+	var options, values []string
+	for _, element := range e.Elements {
+		switch x := element.(type) {
+		case *proto.Option:
+			options = append(options, protoOption(x))
+		case *proto.EnumField:
+			values = append(values, fmt.Sprintf("%s = %d", x.Name, x.Integer))
+		}
+	}
+
+	signature := "enum " + name
+	if len(options) > 0 {
+		signature += " [" + strings.Join(options, ", ") + "]"
+	}
+	signature += " { " + strings.Join(values, "; ") + " }"
+	return Symbol{Kind: "enum", Name: name, Signature: signature, Line: e.Position.Line}
+}
+
+func protoService(s *proto.Service) Symbol {
+	var builder strings.Builder
+	builder.WriteString("service " + s.Name + " {")
+	for _, element := range s.Elements {
+		rpc, ok := element.(*proto.RPC)
+		if !ok {
+			continue
+		}
+		request, response := rpc.RequestType, rpc.ReturnsType
+		if rpc.StreamsRequest {
+			request = "stream " + request
+		}
+		if rpc.StreamsReturns {
+			response = "stream " + response
+		}
+		builder.WriteString(fmt.Sprintf("\n  rpc %s(%s) returns (%s)", rpc.Name, request, response))
+	}
+	builder.WriteString("\n}")
+	return Symbol{Kind: "service", Name: s.Name, Signature: builder.String(), Line: s.Position.Line}
+}
+
+func protoOption(o *proto.Option) string {
+	value := o.Constant.SourceRepresentation()
+	if len(o.AggregatedConstants) > 0 {
+		var parts []string
+		for _, c := range o.AggregatedConstants {
+			parts = append(parts, c.Name+": "+c.Literal.SourceRepresentation())
+		}
+		value = "{ " + strings.Join(parts, ", ") + " }"
+	}
+	return o.Name + " = " + value
+}
@@ -0,0 +1,28 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/piqoni/vogte/config"
+)
+
+func TestProtoImportsStayOutOfGraph(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod":              "module example.com/api\n\ngo 1.22\n",
+		"book/book.proto":     "syntax = \"proto3\";\npackage book;\nimport \"example.com/api/common/common.proto\";\nmessage Book { common.ID id = 1; }\n",
+		"common/common.proto": "syntax = \"proto3\";\npackage common;\nmessage ID { string value = 1; }\n",
+	})
+
+	project, err := New(&config.Config{}).Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if graph := project.importGraph(); len(graph) != 0 {
+		t.Errorf("importGraph = %v, want proto imports left out", graph)
+	}
+	if want := "import \"example.com/api/common/common.proto\"\n"; !strings.Contains(project.String(), want) {
+		t.Errorf("blueprint is missing %q:\n%s", want, project.String())
+	}
+}
@@ -0,0 +1,200 @@
+package parser
+
+import (
+	"bytes"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// stopWords are frequent words in task prompts that carry no signal when
+// matched against identifiers.
+var stopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "with": true, "that": true, "this": true,
+	"from": true, "into": true, "when": true, "should": true, "would": true, "could": true,
+	"add": true, "use": true, "make": true, "new": true, "all": true, "not": true,
+	"are": true, "can": true, "has": true, "have": true, "func": true, "file": true,
+}
+
+// splitIdentifier breaks an identifier or free text into lowercase terms,
+// splitting on camelCase boundaries, digits and punctuation.
+func splitIdentifier(s string) []string {
+	var terms []string
+	var current []rune
+	flush := func() {
+		if len(current) > 0 {
+			terms = append(terms, strings.ToLower(string(current)))
+			current = current[:0]
+		}
+	}
+
+	runes := []rune(s)
+	for i, r := range runes {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+		case unicode.IsUpper(r) && len(current) > 0:
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			// Split "fooBar" and the "B" in "HTTPBody", keep "HTTP" together.
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || nextLower {
+				flush()
+			}
+			current = append(current, r)
+		default:
+			current = append(current, r)
+		}
+	}
+	flush()
+	return terms
+}
+
+// taskTerms returns the set of meaningful terms in a task prompt.
+func taskTerms(task string) map[string]bool {
+	terms := make(map[string]bool)
+	for _, term := range splitIdentifier(task) {
+		if len(term) < 3 || stopWords[term] {
+			continue
+		}
+		terms[term] = true
+	}
+	return terms
+}
+
+// taskWords returns the identifiers mentioned verbatim in a task prompt.
+func taskWords(task string) map[string]bool {
+	words := make(map[string]bool)
+	for _, word := range strings.FieldsFunc(task, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	}) {
+		words[word] = true
+	}
+	return words
+}
+
+// overlap counts how many of the given terms appear in the task.
+func overlap(terms []string, task map[string]bool) float64 {
+	var score float64
+	seen := make(map[string]bool)
+	for _, term := range terms {
+		if task[term] && !seen[term] {
+			seen[term] = true
+			score++
+		}
+	}
+	return score
+}
+
+// symbolScore rates a symbol by how well its name matches the task.
+func symbolScore(sym Symbol, terms, words map[string]bool) float64 {
+	score := 2 * overlap(splitIdentifier(sym.Name), terms)
+	score += overlap(splitIdentifier(sym.Receiver), terms)
+	// Exact mentions such as "ParseProject" are the strongest signal.
+	if words[sym.Name] {
+		score += 3
+	}
+	return score
+}
+
+// fileScores rates every file by identifier overlap with the task, distance in
+// the internal import graph from the best matching packages and recent git
+// activity.
+func fileScores(project *Project, task string) map[*File]float64 {
+	terms, words := taskTerms(task), taskWords(task)
+	scores := make(map[*File]float64)
+	seeds := make(map[string]bool)
+
+	for _, file := range project.Files {
+		score := overlap(splitIdentifier(file.Path), terms)
+		for _, sym := range file.Symbols {
+			score += symbolScore(sym, terms, words)
+		}
+		scores[file] = score
+		if score > 0 {
+			seeds[filepath.Dir(file.Path)] = true
+		}
+	}
+
+	distances := importDistances(project, seeds)
+	changes := recentChanges(project.Dir)
+	for _, file := range project.Files {
+		if d, ok := distances[filepath.Dir(file.Path)]; ok {
+			scores[file] += 1 / float64(d+1)
+		}
+		scores[file] += changes[file.Path]
+	}
+
+	return scores
+}
+
+// importDistances runs a breadth-first search over the internal import graph,
+// treated as undirected, and returns the hop count from the nearest seed
+// package for every reachable package directory.
+func importDistances(project *Project, seeds map[string]bool) map[string]int {
+	graph := make(map[string]map[string]bool)
+	link := func(a, b string) {
+		if graph[a] == nil {
+			graph[a] = make(map[string]bool)
+		}
+		graph[a][b] = true
+	}
+	for _, file := range project.Files {
+		from := filepath.Dir(file.Path)
+		for _, imp := range file.Imports {
+			to := project.importDir(strings.Trim(imp, "\""))
+			if to == "" || to == from {
+				continue
+			}
+			link(from, to)
+			link(to, from)
+		}
+	}
+
+	distances := make(map[string]int)
+	var queue []string
+	for seed := range seeds {
+		distances[seed] = 0
+		queue = append(queue, seed)
+	}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for next := range graph[current] {
+			if _, seen := distances[next]; !seen {
+				distances[next] = distances[current] + 1
+				queue = append(queue, next)
+			}
+		}
+	}
+	return distances
+}
+
+// recentChanges returns a bonus for files with uncommitted changes or touched
+// by recent commits. Outside a git repository it returns an empty map.
+func recentChanges(dir string) map[string]float64 {
+	changes := make(map[string]float64)
+
+	run := func(args ...string) []string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		var out bytes.Buffer
+		cmd.Stdout = &out
+		if err := cmd.Run(); err != nil {
+			return nil
+		}
+		return strings.Split(strings.TrimSpace(out.String()), "\n")
+	}
+
+	for _, path := range run("log", "-n", "20", "--name-only", "--relative", "--format=") {
+		if path != "" && changes[path] == 0 {
+			changes[path] = 0.5
+		}
+	}
+	for _, path := range run("diff", "--name-only", "--relative", "HEAD") {
+		if path != "" {
+			changes[path] = 1
+		}
+	}
+	return changes
+}
@@ -0,0 +1,154 @@
+package parser
+
+import (
+	"fmt"
+	"go/token"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// LoadPackages loads the project in dir restricted to the packages matching
+// patterns, for callers that render it and index it from the same parse. The
+// dependencies section only lists the third-party APIs the selected packages
+// reference.
+func (p *Parser) LoadPackages(dir string, patterns []string) (*Project, error) {
+	project, err := p.load(dir)
+	if err != nil {
+		return nil, err
+	}
+	if err := project.Restrict(patterns); err != nil {
+		return nil, err
+	}
+	if p.config.Context.Dependencies {
+		project.Dependencies = p.parseDependencies(project)
+	}
+	return project, nil
+}
+
+// Restrict limits the project to the packages matching patterns. Patterns are
+// directories relative to the project root, like "./internal/billing", or
+// import paths, and a trailing "/..." also matches every package below. The
+// internal packages their non-test files import, directly or transitively, are
+// kept as dependencies showing their exported signatures only.
+func (proj *Project) Restrict(patterns []string) error {
+	if len(patterns) == 0 {
+		return nil
+	}
+
+	byDir := make(map[string][]*File)
+	for _, file := range proj.Files {
+		dir := filepath.Dir(file.Path)
+		byDir[dir] = append(byDir[dir], file)
+	}
+
+	selected := make(map[string]bool)
+	var queue []string
+	for _, pattern := range patterns {
+		matched := false
+		for dir := range byDir {
+			if proj.matchPackage(pattern, dir) {
+				matched = true
+				if !selected[dir] {
+					selected[dir] = true
+					queue = append(queue, dir)
+				}
+			}
+		}
+		if !matched {
+			return fmt.Errorf("no packages match %s", pattern)
+		}
+	}
+
+	dependencies := make(map[string]bool)
+	for len(queue) > 0 {
+		dir := queue[0]
+		queue = queue[1:]
+		for _, file := range byDir[dir] {
+			// Test imports are not needed to use the package.
+			if isTestFile(file.Path) {
+				continue
+			}
+			for _, imp := range file.Imports {
+				to := proj.importDir(strings.Trim(imp, "\""))
+				if to == "" || selected[to] || dependencies[to] {
+					continue
+				}
+				dependencies[to] = true
+				queue = append(queue, to)
+			}
+		}
+	}
+
+	var files []*File
+	for _, file := range proj.Files {
+		dir := filepath.Dir(file.Path)
+		switch {
+		case selected[dir]:
+			files = append(files, file)
+		case dependencies[dir] && !isTestFile(file.Path):
+			if summary := file.dependencySummary(); len(summary.Symbols) > 0 {
+				files = append(files, summary)
+			}
+		}
+	}
+	proj.Files = files
+	proj.Scope = patterns
+
+	// The types section only describes the selected packages.
+	var entries []typeEntry
+	for _, e := range proj.typeEntries {
+		if selected[filepath.Dir(e.file)] {
+			entries = append(entries, e)
+		}
+	}
+	proj.typeEntries = entries
+	proj.Types = renderTypes(entries)
+	return nil
+}
+
+// matchPackage reports whether the package directory dir, relative to the
+// project root, matches pattern.
+func (proj *Project) matchPackage(pattern, dir string) bool {
+	pattern = filepath.ToSlash(pattern)
+	recursive := pattern == "..." || strings.HasSuffix(pattern, "/...")
+	pattern = strings.TrimSuffix(strings.TrimSuffix(pattern, "..."), "/")
+
+	target := path.Clean(strings.TrimPrefix(pattern, "./"))
+	if pattern != "" && pattern != "." && !strings.HasPrefix(pattern, ".") {
+		// Not a relative directory, so try it as an import path.
+		if importDir := proj.importDir(pattern); importDir != "" {
+			target = importDir
+		}
+	}
+	if pattern == "" {
+		target = "."
+	}
+
+	dir = filepath.ToSlash(dir)
+	if dir == target {
+		return true
+	}
+	return recursive && (target == "." || strings.HasPrefix(dir, target+"/"))
+}
+
+// dependencySummary returns a copy of f reduced to what callers of the package
+// need: exported declarations, with struct fields elided.
+func (f *File) dependencySummary() *File {
+	summary := *f
+	summary.Dependency = true
+	if path.Ext(f.Path) == ".proto" {
+		return &summary
+	}
+	summary.Symbols = nil
+	for _, sym := range f.Symbols {
+		if sym.Kind != "generated" && (!token.IsExported(sym.Name) || sym.Receiver != "" && !token.IsExported(sym.Receiver)) {
+			continue
+		}
+		if sym.Kind == "type" && !strings.HasPrefix(sym.Signature, sym.Name+" interface") {
+			sym.Signature = singleLine(sym.Signature)
+		}
+		summary.Symbols = append(summary.Symbols, sym)
+	}
+	return &summary
+}
@@ -0,0 +1,80 @@
+package parser
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/piqoni/vogte/config"
+)
+
+func TestRestrictRelativeRoot(t *testing.T) {
+	parent := t.TempDir()
+	writeTree(t, filepath.Join(parent, "module"), 3, 1)
+	t.Chdir(parent)
+
+	for _, dir := range []string{"./module", "module", filepath.Join(parent, "module")} {
+		project, err := New(&config.Config{}).LoadPackages(dir, []string{"./pkg001"})
+		if err != nil {
+			t.Fatalf("LoadPackages(%q): %v", dir, err)
+		}
+		var selected, dependencies []string
+		for _, file := range project.Files {
+			if file.Dependency {
+				dependencies = append(dependencies, file.Path)
+			} else {
+				selected = append(selected, file.Path)
+			}
+		}
+		if want := []string{"pkg001/file000.go"}; !slices.Equal(selected, want) {
+			t.Errorf("LoadPackages(%q) selected %v, want %v", dir, selected, want)
+		}
+		if want := []string{"pkg000/file000.go"}; !slices.Equal(dependencies, want) {
+			t.Errorf("LoadPackages(%q) kept dependencies %v, want %v", dir, dependencies, want)
+		}
+	}
+}
+
+func TestRestrictSkipsTestImports(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, 3, 1)
+	writeFiles(t, dir, map[string]string{
+		"pkg000/file000_test.go": "package pkg000\n\nimport \"testing\"\n\nfunc TestType(t *testing.T) {}\n",
+		"pkg001/file000_test.go": "package pkg001_test\n\nimport (\n\t\"testing\"\n\n\t\"example.com/synthetic/pkg002\"\n)\n\nfunc TestDescribe(t *testing.T) { pkg002.Describe000(1) }\n",
+	})
+
+	project, err := New(&config.Config{}).LoadPackages(dir, []string{"./pkg001"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dependencies []string
+	for _, file := range project.Files {
+		if file.Dependency {
+			dependencies = append(dependencies, file.Path)
+		}
+	}
+	if want := []string{"pkg000/file000.go"}; !slices.Equal(dependencies, want) {
+		t.Errorf("kept dependencies %v, want %v", dependencies, want)
+	}
+}
+
+func TestRestrictDependencies(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod":                        "module example.com/app\n\ngo 1.22\n\nrequire example.org/lib v1.0.0\n",
+		"vendor/example.org/lib/lib.go": "package lib\n\n// Used is called by a.\nfunc Used() {}\n\n// Unused is called by b.\nfunc Unused() {}\n",
+		"a/a.go":                        "package a\n\nimport \"example.org/lib\"\n\nfunc A() { lib.Used() }\n",
+		"b/b.go":                        "package b\n\nimport \"example.org/lib\"\n\nfunc B() { lib.Unused() }\n",
+	})
+
+	cfg := &config.Config{}
+	cfg.Context.Dependencies = true
+	project, err := New(cfg).LoadPackages(dir, []string{"./a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(project.Dependencies, "func Used()") || strings.Contains(project.Dependencies, "Unused") {
+		t.Errorf("dependencies section does not match the selected package:\n%s", project.Dependencies)
+	}
+}
@@ -0,0 +1,17 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// HashPassword hashes a password with its salt for storage.
+func HashPassword(password, salt string) string {
+	sum := sha256.Sum256([]byte(salt + password))
+	return hex.EncodeToString(sum[:])
+}
+
+// CheckPassword compares a password with a stored hash.
+func CheckPassword(password, salt, hash string) bool {
+	return HashPassword(password, salt) == hash
+}
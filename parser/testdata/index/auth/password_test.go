@@ -0,0 +1,10 @@
+package auth
+
+import "testing"
+
+func TestPasswordRoundTrip(t *testing.T) {
+	hash := HashPassword("secret", "pepper")
+	if !CheckPassword("secret", "pepper", hash) {
+		t.Fatal("round trip failed")
+	}
+}
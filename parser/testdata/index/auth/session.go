@@ -0,0 +1,15 @@
+package auth
+
+import "time"
+
+// Session is a logged in user, identified by a token until it expires.
+type Session struct {
+	Token   string
+	User    string
+	Expires time.Time
+}
+
+// Expired reports whether the session token is no longer valid.
+func (s *Session) Expired(now time.Time) bool {
+	return now.After(s.Expires)
+}
@@ -0,0 +1,23 @@
+package billing
+
+// Invoice is a bill sent to a customer.
+type Invoice struct {
+	Customer string
+	Lines    []Line
+	TaxRate  float64
+}
+
+// Line is one item of an invoice.
+type Line struct {
+	Description string
+	Amount      int64
+}
+
+// Total returns the invoice total, tax included.
+func (inv *Invoice) Total() int64 {
+	var subtotal int64
+	for _, line := range inv.Lines {
+		subtotal += line.Amount
+	}
+	return subtotal + int64(float64(subtotal)*inv.TaxRate)
+}
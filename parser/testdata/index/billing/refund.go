@@ -0,0 +1,14 @@
+package billing
+
+import "errors"
+
+// ErrRefundTooLarge is returned when a refund exceeds the invoice total.
+var ErrRefundTooLarge = errors.New("refund exceeds the invoice total")
+
+// Refund pays an amount of an invoice back to the customer.
+func Refund(inv *Invoice, amount int64) error {
+	if amount > inv.Total() {
+		return ErrRefundTooLarge
+	}
+	return nil
+}
@@ -0,0 +1,13 @@
+package server
+
+import "net/http"
+
+// Routes registers the HTTP handlers for login and invoices.
+func Routes(mux *http.ServeMux) {
+	mux.HandleFunc("/login", handleLogin)
+	mux.HandleFunc("/invoices", handleInvoices)
+}
+
+func handleLogin(w http.ResponseWriter, r *http.Request) {}
+
+func handleInvoices(w http.ResponseWriter, r *http.Request) {}
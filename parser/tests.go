@@ -0,0 +1,86 @@
+package parser
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// testPrefixes are the function name prefixes go test runs.
+var testPrefixes = []string{"Test", "Benchmark", "Fuzz", "Example"}
+
+// isTestFile reports whether path is a Go test file.
+func isTestFile(path string) bool {
+	return strings.HasSuffix(path, "_test.go")
+}
+
+// summarizeTests reduces each test file to a single symbol listing its tests
+// and helpers, e.g. "tests: TestA, Suite.TestB; helpers: newFixture(...),
+// Suite.setup(...)". That is enough for the model to find where tests live
+// without spending tokens on their signatures, and each entry keeps the path
+// of a file it can ask for.
+func summarizeTests(files []*File) []*File {
+	summarized := make([]*File, 0, len(files))
+	for _, file := range files {
+		if !isTestFile(file.Path) || file.excluded != "" {
+			summarized = append(summarized, file)
+			continue
+		}
+		summary := *file
+		summary.Symbols = nil
+		if sym := testSymbol(file.Symbols); sym.Signature != "" {
+			summary.Symbols = []Symbol{sym}
+		}
+		summarized = append(summarized, &summary)
+	}
+	return summarized
+}
+
+// testSymbol lists the tests of a test file, including test methods of suite
+// types, and its helpers, including the other methods of those types. A part
+// with nothing to list is left out, so a file of helpers only reads
+// "helpers: ...".
+func testSymbol(symbols []Symbol) Symbol {
+	var tests, helpers []string
+	for _, sym := range symbols {
+		switch {
+		case sym.Kind == "func" && isTestFunc(sym.Name):
+			tests = append(tests, sym.Name)
+		case sym.Kind == "method" && isTestFunc(sym.Name):
+			tests = append(tests, sym.Receiver+"."+sym.Name)
+		case sym.Kind == "func":
+			helpers = append(helpers, sym.Name+"(...)")
+		case sym.Kind == "method":
+			helpers = append(helpers, sym.Receiver+"."+sym.Name+"(...)")
+		case sym.Kind == "type":
+			helpers = append(helpers, sym.Name)
+		}
+	}
+
+	var parts []string
+	if len(tests) > 0 {
+		parts = append(parts, "tests: "+strings.Join(tests, ", "))
+	}
+	if len(helpers) > 0 {
+		parts = append(parts, "helpers: "+strings.Join(helpers, ", "))
+	}
+	return Symbol{Kind: "tests", Name: strings.Join(tests, ", "), Signature: strings.Join(parts, "; ")}
+}
+
+// isTestFunc applies the go test naming rule: a prefix such as "Test" followed
+// by nothing or by a character that is not a lower-case letter.
+func isTestFunc(name string) bool {
+	for _, prefix := range testPrefixes {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		if len(name) == len(prefix) {
+			return true
+		}
+		r, _ := utf8.DecodeRuneInString(name[len(prefix):])
+		if !unicode.IsLower(r) {
+			return true
+		}
+	}
+	return false
+}
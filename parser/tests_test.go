@@ -0,0 +1,60 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/piqoni/vogte/config"
+)
+
+func TestSummarizeTests(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod":   "module example.com/suite\n\ngo 1.22\n",
+		"store.go": "package store\n\nfunc Open() {}\n",
+		"open_test.go": `package store
+
+import "testing"
+
+func TestOpen(t *testing.T) {}
+
+func newFixture() {}
+`,
+		"suite_test.go": `package store
+
+import "testing"
+
+type Suite struct{}
+
+func (s *Suite) TestLoad(t *testing.T) {}
+
+func (s *Suite) setup() {}
+`,
+		"helpers_test.go": `package store
+
+func tempStore() {}
+`,
+	})
+
+	project, err := New(&config.Config{}).Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"open_test.go":    "tests: TestOpen; helpers: newFixture(...)",
+		"suite_test.go":   "tests: Suite.TestLoad; helpers: Suite, Suite.setup(...)",
+		"helpers_test.go": "helpers: tempStore(...)",
+	}
+	for _, file := range project.Files {
+		signature, ok := want[file.Path]
+		if !ok {
+			continue
+		}
+		delete(want, file.Path)
+		if len(file.Symbols) != 1 || file.Symbols[0].Signature != signature {
+			t.Errorf("%s symbols = %v, want one with signature %q", file.Path, file.Symbols, signature)
+		}
+	}
+	for path := range want {
+		t.Errorf("no entry for %s", path)
+	}
+}
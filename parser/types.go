@@ -0,0 +1,253 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// namedType is a type declared in one of the module packages, along with the
+// file (relative to the project root) where it is declared.
+type namedType struct {
+	obj  *types.TypeName
+	file string
+}
+
+// typeEntry is the rendered type-checked view of one named type, kept per
+// file so the section can be narrowed along with the files.
+type typeEntry struct {
+	file string
+	text string
+}
+
+// renderTypes joins the entries into the types section.
+func renderTypes(entries []typeEntry) string {
+	if len(entries) == 0 {
+		return ""
+	}
+	var builder strings.Builder
+	builder.WriteString("types:\n")
+	for _, e := range entries {
+		builder.WriteString(e.text)
+	}
+	return builder.String()
+}
+
+// parseTypes loads each module of the project with go/packages and renders a
+// type-checked view of every named type declared in the project's files: fully
+// qualified method signatures, resolved embedded fields and, for interfaces,
+// the concrete types that implement them. Packages are loaded for the
+// configured GOOS, GOARCH and build tags, so the view covers the same files as
+// the blueprint. Type errors are returned as diagnostics; syntax errors are
+// left to the file parser, which reports them already.
+func (p *Parser) parseTypes(dir string, project *Project) ([]typeEntry, []Diagnostic, error) {
+	// go/packages reports absolute file names.
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	ctxt := p.buildContext()
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedSyntax |
+			packages.NeedImports | packages.NeedDeps | packages.NeedTypes | packages.NeedTypesInfo,
+		Env: append(os.Environ(), "GOOS="+ctxt.GOOS, "GOARCH="+ctxt.GOARCH),
+	}
+	if tags := p.config.Context.Tags; len(tags) > 0 {
+		cfg.BuildFlags = []string{"-tags=" + strings.Join(tags, ",")}
+	}
+	var pkgs []*packages.Package
+	var diagnostics []Diagnostic
+	for _, loadDir := range typeCheckDirs(dir, project.Modules) {
+		cfg.Dir = loadDir
+		loaded, err := packages.Load(cfg, "./...")
+		if err != nil {
+			file, _ := relativePath(dir, loadDir)
+			diagnostics = append(diagnostics, Diagnostic{File: file, Message: typeCheckFailed + err.Error(), Severity: SeverityWarning})
+			continue
+		}
+		pkgs = append(pkgs, loaded...)
+	}
+	sort.Slice(pkgs, func(i, j int) bool { return pkgs[i].PkgPath < pkgs[j].PkgPath })
+
+	// Only types from the files the blueprint summarizes are described.
+	summarized := make(map[string]bool)
+	for _, file := range project.Files {
+		if file.excluded == "" && strings.HasSuffix(file.Path, ".go") {
+			summarized[file.Path] = true
+		}
+	}
+
+	var named []namedType
+	for _, pkg := range pkgs {
+		for _, e := range pkg.Errors {
+			if e.Kind == packages.ParseError {
+				continue
+			}
+			msg := e.Msg
+			if e.Pos != "" && e.Pos != "-" {
+				msg = e.Pos + ": " + e.Msg
+			}
+			d := diagnosticsFromError(pkg.PkgPath, errors.New(msg))[0]
+			d.Severity = SeverityWarning
+			if file, _, ok := strings.Cut(e.Pos, ":"); ok {
+				if rel, err := relativePath(dir, file); err == nil {
+					d.File = rel
+				}
+			}
+			diagnostics = append(diagnostics, d)
+		}
+		if pkg.Types == nil {
+			continue
+		}
+		scope := pkg.Types.Scope()
+		for _, name := range scope.Names() {
+			obj, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok || obj.IsAlias() {
+				continue
+			}
+			if _, ok := obj.Type().(*types.Named); !ok {
+				continue
+			}
+			rel, err := relativePath(dir, pkg.Fset.Position(obj.Pos()).Filename)
+			if err != nil || !summarized[rel] {
+				continue
+			}
+			named = append(named, namedType{obj: obj, file: rel})
+		}
+	}
+
+	var entries []typeEntry
+	for _, nt := range named {
+		var builder strings.Builder
+		t := nt.obj.Type().(*types.Named)
+		switch u := t.Underlying().(type) {
+		case *types.Interface:
+			builder.WriteString(fmt.Sprintf("interface %s (%s)\n", typeName(t), nt.file))
+			for i := 0; i < u.NumMethods(); i++ {
+				builder.WriteString("  method " + methodString(u.Method(i), false) + "\n")
+			}
+			if impls := implementations(t, named); len(impls) > 0 {
+				builder.WriteString("  implemented by: " + strings.Join(impls, ", ") + "\n")
+			}
+		case *types.Struct:
+			builder.WriteString(fmt.Sprintf("type %s struct (%s)\n", typeName(t), nt.file))
+			p.writeEmbedded(&builder, t, u)
+			p.writeMethods(&builder, t)
+		default:
+			qf := types.RelativeTo(nt.obj.Pkg())
+			builder.WriteString(fmt.Sprintf("type %s %s (%s)\n", typeName(t), types.TypeString(u, qf), nt.file))
+			p.writeMethods(&builder, t)
+		}
+		entries = append(entries, typeEntry{file: nt.file, text: builder.String()})
+	}
+
+	return entries, diagnostics, nil
+}
+
+// typeCheckDirs returns the directories to load packages from: each module
+// under the project root, or the root itself when it lies inside a module.
+// Loading per module rather than "./..." from the root also works when the
+// root is a go.work directory that is not a module itself.
+func typeCheckDirs(root string, modules []*Module) []string {
+	var dirs []string
+	seen := make(map[string]bool)
+	for _, m := range modules {
+		var loadDir string
+		switch {
+		case m.abs == root || strings.HasPrefix(m.abs, root+string(filepath.Separator)):
+			loadDir = m.abs
+		case strings.HasPrefix(root, m.abs+string(filepath.Separator)):
+			loadDir = root
+		default:
+			// A workspace module outside the root has no summarized files.
+			continue
+		}
+		if !seen[loadDir] {
+			seen[loadDir] = true
+			dirs = append(dirs, loadDir)
+		}
+	}
+	return dirs
+}
+
+// writeEmbedded lists the embedded fields of a struct and the methods each one
+// promotes to the outer type.
+func (p *Parser) writeEmbedded(builder *strings.Builder, t *types.Named, s *types.Struct) {
+	mset := types.NewMethodSet(types.NewPointer(t))
+	for i := 0; i < s.NumFields(); i++ {
+		field := s.Field(i)
+		if !field.Embedded() {
+			continue
+		}
+		var promoted []string
+		for j := 0; j < mset.Len(); j++ {
+			sel := mset.At(j)
+			if len(sel.Index()) > 1 && sel.Index()[0] == i {
+				promoted = append(promoted, sel.Obj().Name())
+			}
+		}
+		line := "  embeds " + types.TypeString(field.Type(), types.RelativeTo(t.Obj().Pkg()))
+		if len(promoted) > 0 {
+			line += " (promotes: " + strings.Join(promoted, ", ") + ")"
+		}
+		builder.WriteString(line + "\n")
+	}
+}
+
+// writeMethods lists the methods declared directly on t, including those with
+// pointer receivers.
+func (p *Parser) writeMethods(builder *strings.Builder, t *types.Named) {
+	for i := 0; i < t.NumMethods(); i++ {
+		builder.WriteString("  method " + methodString(t.Method(i), true) + "\n")
+	}
+}
+
+// implementations returns the module types (or pointers to them) that satisfy
+// the given interface. Generic types are skipped since they cannot be checked
+// without instantiation.
+func implementations(iface *types.Named, named []namedType) []string {
+	it, ok := iface.Underlying().(*types.Interface)
+	if !ok || it.NumMethods() == 0 || iface.TypeParams().Len() > 0 {
+		return nil
+	}
+
+	var impls []string
+	for _, nt := range named {
+		t := nt.obj.Type().(*types.Named)
+		if types.IsInterface(t) || t.TypeParams().Len() > 0 {
+			continue
+		}
+		if types.Implements(t, it) {
+			impls = append(impls, fmt.Sprintf("%s (%s)", typeName(t), nt.file))
+		} else if types.Implements(types.NewPointer(t), it) {
+			impls = append(impls, fmt.Sprintf("*%s (%s)", typeName(t), nt.file))
+		}
+	}
+	return impls
+}
+
+// methodString renders a method signature with types from other packages fully
+// qualified by import path and types from the declaring package left bare.
+func methodString(fn *types.Func, withRecv bool) string {
+	sig := fn.Type().(*types.Signature)
+	qf := types.RelativeTo(fn.Pkg())
+	recv := ""
+	if withRecv && sig.Recv() != nil {
+		recv = "(" + types.TypeString(sig.Recv().Type(), qf) + ") "
+	}
+	return recv + fn.Name() + strings.TrimPrefix(types.TypeString(sig, qf), "func")
+}
+
+func typeName(t *types.Named) string {
+	obj := t.Obj()
+	if obj.Pkg() == nil {
+		return obj.Name()
+	}
+	return obj.Pkg().Path() + "." + obj.Name()
+}
@@ -0,0 +1,86 @@
+package parser
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/piqoni/vogte/config"
+)
+
+func TestParseTypesWorkspace(t *testing.T) {
+	// The root holds only go.work, so "./..." from it matches no module.
+	parent := t.TempDir()
+	root := filepath.Join(parent, "work")
+	writeFiles(t, root, map[string]string{
+		"go.work":        "go 1.22\n\nuse (\n\t./api\n\t./store\n)\n",
+		"api/go.mod":     "module example.com/api\n\ngo 1.22\n",
+		"api/api.go":     "package api\n\n// Handler serves requests.\ntype Handler interface{ Serve() error }\n",
+		"store/go.mod":   "module example.com/store\n\ngo 1.22\n",
+		"store/store.go": "package store\n\n// Store keeps records.\ntype Store struct{}\n\nfunc (s *Store) Serve() error { return nil }\n",
+	})
+	t.Chdir(parent)
+	// -mod=mod, set by some environments, is rejected in workspace mode.
+	t.Setenv("GOFLAGS", "")
+
+	cfg := &config.Config{}
+	cfg.Context.TypeCheck = true
+	project, err := New(cfg).Load("./work")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range project.Diagnostics {
+		t.Errorf("unexpected diagnostic: %v", d)
+	}
+	for _, want := range []string{
+		"interface example.com/api.Handler (api/api.go)",
+		"type example.com/store.Store struct (store/store.go)",
+	} {
+		if !strings.Contains(project.Types, want) {
+			t.Errorf("types section is missing %q:\n%s", want, project.Types)
+		}
+	}
+}
+
+func TestTypeErrorsKeepFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod":     "module example.com/broken\n\ngo 1.22\n",
+		"typed.go":   "package broken\n\nvar n int = \"text\"\n",
+		"invalid.go": "package broken\n\nfunc {\n",
+	})
+
+	cfg := &config.Config{}
+	cfg.Context.TypeCheck = true
+	project, err := New(cfg).Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leftOut := make(map[string]bool)
+	for _, d := range project.Diagnostics {
+		leftOut[d.File] = leftOut[d.File] || d.LeftOut()
+	}
+	if !leftOut["invalid.go"] {
+		t.Errorf("syntax error in invalid.go is not reported as left out: %v", project.Diagnostics)
+	}
+	if _, ok := leftOut["typed.go"]; !ok || leftOut["typed.go"] {
+		t.Errorf("type error in typed.go is not reported as a warning: %v", project.Diagnostics)
+	}
+
+	section := renderDiagnostics(project.Diagnostics, maxRenderedDiagnostics)
+	errors, warnings, _ := strings.Cut(section, "type errors")
+	if !strings.Contains(errors, "invalid.go") || strings.Contains(errors, "typed.go") || !strings.Contains(warnings, "typed.go") {
+		t.Errorf("diagnostics section does not separate type errors:\n%s", section)
+	}
+}
+
+func TestTypeCheckFailureIsNotATypeError(t *testing.T) {
+	section := renderDiagnostics([]Diagnostic{
+		{File: "api", Message: typeCheckFailed + "go command not found", Severity: SeverityWarning},
+		{File: "store/store.go", Line: 3, Column: 13, Message: "undefined: x", Severity: SeverityWarning},
+	}, maxRenderedDiagnostics)
+	typeErrors, failures, ok := strings.Cut(section, "type check failures")
+	if !ok || strings.Contains(typeErrors, "api: ") || !strings.Contains(failures, "api: type check failed") || strings.Contains(failures, "store.go") {
+		t.Errorf("diagnostics section lists the load failure with the type errors:\n%s", section)
+	}
+}
@@ -0,0 +1,216 @@
+package parser
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// skippedDirs are never descended into, regardless of configuration.
+var skippedDirs = map[string]bool{
+	"vendor":       true,
+	"node_modules": true,
+}
+
+// ignorePattern is a single rule from a .gitignore file.
+type ignorePattern struct {
+	base    string // directory of the .gitignore, relative to the walk root
+	pattern string
+	negate  bool
+	dirOnly bool
+	rooted  bool // pattern contains a slash and only matches relative to base
+}
+
+// walker decides which files under the project root are summarized. It
+// combines .gitignore rules, the default skipped directories and the include
+// and exclude globs from the config.
+type walker struct {
+	root    string
+	include []string
+	exclude []string
+	ignores []ignorePattern
+}
+
+// walk calls fn for every file under dir that passes the ignore rules, in
+// lexical order. Entries that cannot be read are skipped and reported as
+// diagnostics; only a failure to read dir itself is returned as an error.
+func (p *Parser) walk(dir string, fn func(path string, info os.FileInfo) error) ([]Diagnostic, error) {
+	w := &walker{
+		root:    dir,
+		include: p.config.Context.Include,
+		exclude: p.config.Context.Exclude,
+	}
+	w.loadIgnoreFile(filepath.Join(dir, ".git", "info", "exclude"), "")
+
+	var diagnostics []Diagnostic
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil && path == dir {
+			return err
+		}
+		rel, relErr := relativePath(dir, path)
+		if relErr != nil {
+			return relErr
+		}
+		if err != nil {
+			diagnostics = append(diagnostics, Diagnostic{File: rel, Message: err.Error(), Severity: SeverityError})
+			if info != nil && info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if info.IsDir() {
+			if rel == "." {
+				w.loadIgnoreFile(filepath.Join(path, ".gitignore"), "")
+				return nil
+			}
+			if w.skipDir(rel, info.Name()) {
+				return filepath.SkipDir
+			}
+			w.loadIgnoreFile(filepath.Join(path, ".gitignore"), rel)
+			return nil
+		}
+
+		if !w.includeFile(rel) {
+			return nil
+		}
+		return fn(path, info)
+	})
+	return diagnostics, err
+}
+
+func (w *walker) skipDir(rel, name string) bool {
+	if strings.HasPrefix(name, ".") || skippedDirs[name] {
+		return true
+	}
+	if w.ignored(rel, true) {
+		return true
+	}
+	for _, pattern := range w.exclude {
+		if matchGlob(pattern, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *walker) includeFile(rel string) bool {
+	// Module files are always needed to attribute files to modules.
+	if name := path.Base(rel); name == "go.mod" || name == "go.work" {
+		return true
+	}
+	if w.ignored(rel, false) {
+		return false
+	}
+	for _, pattern := range w.exclude {
+		if matchGlob(pattern, rel) {
+			return false
+		}
+	}
+	if len(w.include) == 0 {
+		return true
+	}
+	for _, pattern := range w.include {
+		if matchGlob(pattern, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// ignored applies the loaded .gitignore rules in order, so later rules and
+// rules from deeper directories take precedence, as in git.
+func (w *walker) ignored(rel string, isDir bool) bool {
+	ignored := false
+	for _, rule := range w.ignores {
+		sub := rel
+		if rule.base != "" {
+			if !strings.HasPrefix(rel, rule.base+"/") {
+				continue
+			}
+			sub = strings.TrimPrefix(rel, rule.base+"/")
+		}
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		target := sub
+		if !rule.rooted {
+			target = path.Base(sub)
+		}
+		if matchGlob(rule.pattern, target) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// loadIgnoreFile reads the patterns of a .gitignore-style file, if present.
+func (w *walker) loadIgnoreFile(filePath, base string) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rule := ignorePattern{base: base}
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+		if strings.Contains(line, "/") {
+			rule.rooted = true
+			line = strings.TrimPrefix(line, "/")
+		}
+		line = strings.TrimPrefix(line, "\\")
+		if line == "" {
+			continue
+		}
+		rule.pattern = line
+		w.ignores = append(w.ignores, rule)
+	}
+}
+
+// matchGlob reports whether the slash-separated path matches pattern. A "**"
+// segment matches any number of directories, including none. Patterns without
+// a slash are matched against the base name only, like .gitignore entries.
+func matchGlob(pattern, name string) bool {
+	pattern = strings.TrimPrefix(pattern, "./")
+	if !strings.Contains(pattern, "/") && pattern != "**" {
+		ok, _ := path.Match(pattern, path.Base(name))
+		return ok
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
@@ -5,10 +5,13 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/piqoni/vogte/parser"
 )
 
 type Patcher struct {
 	baseDir string
+	force   bool // allow edits to generated files
 }
 
 func New(baseDir string) *Patcher {
@@ -17,6 +20,12 @@ func New(baseDir string) *Patcher {
 	}
 }
 
+// SetForce allows patches to modify files carrying a "Code generated ... DO NOT
+// EDIT" header, which are refused by default.
+func (pc *Patcher) SetForce(force bool) {
+	pc.force = force
+}
+
 func (pc *Patcher) ParseAndApply(patchContent string) error {
 
 	// if err := os.WriteFile("debug.log", []byte(patchContent), 0644); err != nil {
@@ -193,6 +202,9 @@ func (pc *Patcher) applyPatch(filename, context string, removals, additions []st
 		if err != nil {
 			return fmt.Errorf("failed to read file %s: %w", filePath, err)
 		}
+		if generator, ok := parser.GeneratedBy(content); ok && !pc.force {
+			return fmt.Errorf("refusing to edit %s: generated by %s (DO NOT EDIT), change its source instead", filename, generator)
+		}
 	}
 
 	lines := strings.Split(string(content), "\n")
@@ -0,0 +1,35 @@
+package patcher
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGeneratedFilesNeedForce(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "queries.go")
+	src := "// Code generated by sqlc. DO NOT EDIT.\n\npackage db\n\nconst limit = 10\n"
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	patch := "*** Begin Patch\n*** Update File: queries.go\n@@ package db\n-const limit = 10\n+const limit = 20\n*** End Patch\n"
+
+	pc := New(dir)
+	err := pc.ParseAndApply(patch)
+	if err == nil || !strings.Contains(err.Error(), "generated by sqlc") {
+		t.Errorf("ParseAndApply on a generated file returned %v, want a refusal", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != src {
+		t.Errorf("generated file was modified:\n%s", data)
+	}
+
+	pc.SetForce(true)
+	if err := pc.ParseAndApply(patch); err != nil {
+		t.Fatalf("ParseAndApply with force: %v", err)
+	}
+	if data, _ := os.ReadFile(path); !strings.Contains(string(data), "const limit = 20") {
+		t.Errorf("forced patch was not applied:\n%s", data)
+	}
+}
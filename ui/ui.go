@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gdamore/tcell/v2"
@@ -32,6 +33,14 @@ type UI struct {
 	modelName    string
 
 	chatBuffer string
+	// lineColored is set once the color of the last line in the chat view is
+	// decided, and pendingLine holds its blank start until then.
+	lineColored bool
+	pendingLine string
+
+	// queued collects streamed text until the next draw appends it.
+	queuedMu sync.Mutex
+	queued   strings.Builder
 	// spinner animation
 	isLoading      bool
 	animationFrame int
@@ -250,27 +259,85 @@ func (ui *UI) colorizeText(text string) string {
 	var colorizedLines []string
 
 	for _, line := range lines {
-		trimmedLine := strings.TrimSpace(line)
-		if strings.HasPrefix(trimmedLine, "+") {
-			colorizedLines = append(colorizedLines, "[black:green]+[-:-]"+line[1:])
-		} else if strings.HasPrefix(trimmedLine, "-") {
-			colorizedLines = append(colorizedLines, "[black:red]-[-:-]"+line[1:])
-		} else {
-			colorizedLines = append(colorizedLines, line)
-		}
+		colorizedLines = append(colorizedLines, colorizeLine(line))
 	}
 
 	return strings.Join(colorizedLines, "\n")
 }
 
+// colorizeLine highlights the marker of an added or removed diff line. Only
+// the start of the line is needed to decide.
+func colorizeLine(line string) string {
+	trimmedLine := strings.TrimSpace(line)
+	if strings.HasPrefix(trimmedLine, "+") {
+		return "[black:green]+[-:-]" + line[1:]
+	} else if strings.HasPrefix(trimmedLine, "-") {
+		return "[black:red]-[-:-]" + line[1:]
+	}
+	return line
+}
+
 func (ui *UI) SetChatText(text string) {
 	ui.chatBuffer = text
+	ui.lineColored = text != "" && !strings.HasSuffix(text, "\n")
+	ui.pendingLine = ""
 	colorizedText := ui.colorizeText(text)
 	ui.chatView.SetText(colorizedText)
 }
 
+// AppendChatText adds text to the end of the chat. Only the new text is
+// colorized and written to the view, so appending stays cheap as the chat
+// grows.
 func (ui *UI) AppendChatText(text string) {
 	ui.chatBuffer += text
-	colorizedText := ui.colorizeText(ui.chatBuffer)
-	ui.chatView.SetText(colorizedText)
+
+	var out strings.Builder
+	for text != "" {
+		chunk := text
+		if i := strings.IndexByte(text, '\n'); i >= 0 {
+			chunk = text[:i+1]
+		}
+		text = text[len(chunk):]
+
+		if ui.lineColored {
+			out.WriteString(chunk)
+		} else {
+			// The color of a line is known from its first non-blank
+			// character, so a blank start is held back until then.
+			ui.pendingLine += chunk
+			if strings.TrimSpace(ui.pendingLine) != "" || strings.HasSuffix(chunk, "\n") {
+				out.WriteString(colorizeLine(ui.pendingLine))
+				ui.pendingLine = ""
+				ui.lineColored = true
+			}
+		}
+		if strings.HasSuffix(chunk, "\n") {
+			ui.lineColored = false
+		}
+	}
+	ui.chatView.Write([]byte(out.String()))
+}
+
+// QueueChatText appends text to the chat from any goroutine. Text queued
+// before the next draw is appended in a single update, so a fast stream
+// redraws the chat once per frame rather than once per token.
+func (ui *UI) QueueChatText(text string) {
+	if text == "" {
+		return
+	}
+	ui.queuedMu.Lock()
+	first := ui.queued.Len() == 0
+	ui.queued.WriteString(text)
+	ui.queuedMu.Unlock()
+	if first {
+		ui.app.QueueUpdateDraw(ui.flushQueuedText)
+	}
+}
+
+func (ui *UI) flushQueuedText() {
+	ui.queuedMu.Lock()
+	text := ui.queued.String()
+	ui.queued.Reset()
+	ui.queuedMu.Unlock()
+	ui.AppendChatText(text)
 }